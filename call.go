@@ -0,0 +1,162 @@
+package dm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// CallArg 存储过程或函数的参数，按名称传递，参数顺序可以与定义不同
+type CallArg struct {
+	Name   string
+	Value  any
+	out    bool
+	in     bool
+	cursor bool
+}
+
+// In 输入参数
+func In(name string, value any) CallArg {
+	return CallArg{Name: name, Value: value, in: true}
+}
+
+// Out 输出参数，dest为接收结果的指针
+func Out(name string, dest any) CallArg {
+	return CallArg{Name: name, Value: dest, out: true}
+}
+
+// InOut 输入输出参数，dest指向的值作为输入，调用后写入输出
+func InOut(name string, dest any) CallArg {
+	return CallArg{Name: name, Value: dest, in: true, out: true}
+}
+
+// Cursor 游标输出参数，结果按模型的列映射读取到dest，dest为 *[]T 或 *[]*T
+func Cursor(name string, dest any) CallArg {
+	return CallArg{Name: name, Value: dest, out: true, cursor: true}
+}
+
+// Call 调用存储过程，过程名和参数名与其他标识符一样加引号，区分大小写
+//
+//	var total int64
+//	var orders []Order
+//	err := dm.Call(db, "SHOP.LIST_ORDERS", dm.In("P_USER", 1), dm.Out("P_TOTAL", &total), dm.Cursor("P_ORDERS", &orders))
+func Call(db *gorm.DB, procedure string, args ...CallArg) error {
+	return call(db, "", procedure, nil, args)
+}
+
+// CallFunction 调用函数，返回值写入result指向的变量
+func CallFunction(db *gorm.DB, function string, result any, args ...CallArg) error {
+	return call(db, "? := ", function, &sql.Out{Dest: result}, args)
+}
+
+func call(db *gorm.DB, assign, name string, result *sql.Out, args []CallArg) error {
+	var (
+		params  = make([]string, len(args))
+		vars    = make([]any, 0, len(args)+1)
+		cursors = make(map[int]*DmRows)
+	)
+	if result != nil {
+		vars = append(vars, *result)
+	}
+	for i, arg := range args {
+		params[i] = db.Statement.Quote(arg.Name) + " => ?"
+		switch {
+		case arg.cursor:
+			rows := &DmRows{}
+			cursors[i] = rows
+			vars = append(vars, sql.Out{Dest: rows})
+		case arg.out:
+			vars = append(vars, sql.Out{Dest: arg.Value, In: arg.in})
+		default:
+			vars = append(vars, arg.Value)
+		}
+	}
+
+	// 名称与其他标识符一样加引号，大写标识符模式下转为大写
+	tx := db.Exec("BEGIN "+assign+db.Statement.Quote(name)+"("+strings.Join(params, ", ")+"); END;", vars...)
+	if tx.Error != nil || tx.DryRun {
+		return tx.Error
+	}
+
+	var errs []error
+	for i, rows := range cursors {
+		if err := scanCursor(tx, rows, args[i].Value); err != nil {
+			errs = append(errs, fmt.Errorf("failed to scan cursor %s: %w", args[i].Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scanCursor 将游标的结果读取到结构体切片，列名按gorm的字段映射匹配，不区分大小写
+func scanCursor(db *gorm.DB, rows *DmRows, dest any) error {
+	// 过程没有打开游标时没有需要关闭的结果集
+	if rows.CurrentRows != nil {
+		defer rows.Close()
+	}
+
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cursor destination must be a pointer to slice, got %T", dest)
+	}
+	sliceValue := destValue.Elem()
+	sliceValue.SetLen(0)
+	// 过程没有打开游标
+	if rows.CurrentRows == nil {
+		return nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(dest); err != nil {
+		return err
+	}
+
+	columns := rows.Columns()
+	fields := make([]*schema.Field, len(columns))
+	for i, column := range columns {
+		if fields[i] = stmt.Schema.LookUpField(column); fields[i] == nil {
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName != "" && strings.EqualFold(field.DBName, column) {
+					fields[i] = field
+					break
+				}
+			}
+		}
+	}
+
+	var (
+		elemType = sliceValue.Type().Elem()
+		isPtr    = elemType.Kind() == reflect.Ptr
+		values   = make([]driver.Value, len(columns))
+	)
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	for {
+		if err := rows.Next(values); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		elem := reflect.New(elemType)
+		for i, field := range fields {
+			if field != nil {
+				if err := field.Set(db.Statement.Context, elem.Elem(), values[i]); err != nil {
+					return err
+				}
+			}
+		}
+		if isPtr {
+			sliceValue.Set(reflect.Append(sliceValue, elem))
+		} else {
+			sliceValue.Set(reflect.Append(sliceValue, elem.Elem()))
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package dm
+
+import (
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ConstraintType SYS.SYSCONS.TYPE$ 中的约束类型
+type ConstraintType string
+
+const (
+	ConstraintPrimaryKey ConstraintType = "P"
+	ConstraintUnique     ConstraintType = "U"
+	ConstraintForeignKey ConstraintType = "F"
+	ConstraintCheck      ConstraintType = "C"
+)
+
+// Constraint GetConstraints返回的约束
+type Constraint struct {
+	Name    string
+	Type    ConstraintType
+	Columns []string
+	// 外键引用的表和列，以及 ON UPDATE / ON DELETE 动作
+	RefSchema  string
+	RefTable   string
+	RefColumns []string
+	OnUpdate   string
+	OnDelete   string
+	// CHECK约束的条件
+	Check string
+}
+
+// GetConstraints 查询表上的主键、唯一、外键和CHECK约束，列按在约束中的顺序排列
+func (m Migrator) GetConstraints(value any) ([]Constraint, error) {
+	consSql := `SELECT /*+ MAX_OPT_N_TABLES(5) */ CON_OBJ.NAME, CONS.TYPE$, CONS.INDEXID, CONS.FINDEXID, CONS.CHECKINFO, CONS.FACTION FROM
+(SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) SCHS,
+(SELECT ID, SCHID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ = 'UTAB' AND NAME = ?) TABS,
+SYS.SYSCONS CONS, (SELECT ID, NAME FROM SYS.SYSOBJECTS WHERE SUBTYPE$ = 'CONS') CON_OBJ
+WHERE TABS.SCHID = SCHS.ID AND CONS.TABLEID = TABS.ID AND CON_OBJ.ID = CONS.ID ORDER BY CON_OBJ.NAME`
+	// 约束使用的索引上的列，外键引用的索引同时给出所在的表
+	indexSql := `SELECT /*+ MAX_OPT_N_TABLES(5) */ OBJ_INDS.ID, SCHS.NAME, TABS.NAME, COLS.NAME FROM
+SYS.SYSOBJECTS OBJ_INDS, SYS.SYSINDEXES INDS, SYS.SYSCOLUMNS COLS, SYS.SYSOBJECTS TABS, SYS.SYSOBJECTS SCHS
+WHERE OBJ_INDS.ID IN ? AND INDS.ID = OBJ_INDS.ID AND TABS.ID = OBJ_INDS.PID AND SCHS.ID = TABS.SCHID AND COLS.ID = TABS.ID
+AND SF_COL_IS_IDX_KEY(INDS.KEYNUM, INDS.KEYINFO, COLS.COLID) = 1
+ORDER BY OBJ_INDS.ID, SF_GET_INDEX_KEY_SEQ(INDS.KEYNUM, INDS.KEYINFO, COLS.COLID)`
+
+	var constraints []Constraint
+	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		type consRow struct {
+			constraint        Constraint
+			indexID, refIndex int64
+		}
+
+		schemaName, tableName := m.tableSchema(stmt)
+		rows, err := m.DB.Raw(consSql, schemaName, tableName).Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var (
+			cons     []consRow
+			indexIDs []int64
+		)
+		for rows.Next() {
+			var (
+				row     consRow
+				check   *string
+				faction *string
+			)
+			if err := rows.Scan(&row.constraint.Name, &row.constraint.Type, &row.indexID, &row.refIndex, &check, &faction); err != nil {
+				return err
+			}
+			if check != nil {
+				row.constraint.Check = *check
+			}
+			if faction != nil && row.constraint.Type == ConstraintForeignKey {
+				row.constraint.OnUpdate, row.constraint.OnDelete = foreignKeyActions(*faction)
+			}
+			for _, id := range []int64{row.indexID, row.refIndex} {
+				if id > 0 {
+					indexIDs = append(indexIDs, id)
+				}
+			}
+			cons = append(cons, row)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(cons) == 0 {
+			return nil
+		}
+
+		type indexInfo struct {
+			schema, table string
+			columns       []string
+		}
+		indexes := make(map[int64]*indexInfo)
+		if len(indexIDs) > 0 {
+			indexRows, err := m.DB.Raw(indexSql, indexIDs).Rows()
+			if err != nil {
+				return err
+			}
+			defer indexRows.Close()
+			for indexRows.Next() {
+				var (
+					id                        int64
+					schemaName, table, column string
+				)
+				if err := indexRows.Scan(&id, &schemaName, &table, &column); err != nil {
+					return err
+				}
+				if indexes[id] == nil {
+					indexes[id] = &indexInfo{schema: schemaName, table: table}
+				}
+				indexes[id].columns = append(indexes[id].columns, column)
+			}
+			if err := indexRows.Err(); err != nil {
+				return err
+			}
+		}
+
+		for _, row := range cons {
+			if index, ok := indexes[row.indexID]; ok {
+				row.constraint.Columns = index.columns
+			}
+			if ref, ok := indexes[row.refIndex]; ok && row.constraint.Type == ConstraintForeignKey {
+				row.constraint.RefSchema, row.constraint.RefTable, row.constraint.RefColumns = ref.schema, ref.table, ref.columns
+			}
+			constraints = append(constraints, row.constraint)
+		}
+		return nil
+	})
+	return constraints, err
+}
+
+// foreignKeyActions FACTION的第一个字符为更新动作，第二个字符为删除动作
+func foreignKeyActions(faction string) (onUpdate, onDelete string) {
+	action := func(c byte) string {
+		switch c {
+		case 'C':
+			return "CASCADE"
+		case 'S':
+			return "SET NULL"
+		case 'D':
+			return "SET DEFAULT"
+		}
+		return "NO ACTION"
+	}
+	faction += "  "
+	return action(faction[0]), action(faction[1])
+}
+
+// recreateDriftedConstraints 删除与模型定义不一致的外键和CHECK约束，并立即按模型重新创建
+func (m Migrator) recreateDriftedConstraints(value any) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		var (
+			foreignKeys = make(map[string]*schema.Constraint)
+			checks      = stmt.Schema.ParseCheckConstraints()
+		)
+		if !m.DB.DisableForeignKeyConstraintWhenMigrating && !m.DB.IgnoreRelationshipsWhenMigrating {
+			for _, rel := range stmt.Schema.Relationships.Relations {
+				if rel.Field.IgnoreMigration {
+					continue
+				}
+				if constraint := rel.ParseConstraint(); constraint != nil && constraint.Schema == stmt.Schema {
+					foreignKeys[strings.ToUpper(constraint.Name)] = constraint
+				}
+			}
+		}
+		if len(foreignKeys) == 0 && len(checks) == 0 || !m.HasTable(value) {
+			return nil
+		}
+
+		existing, err := m.GetConstraints(value)
+		if err != nil {
+			return err
+		}
+		for _, current := range existing {
+			var reason string
+			switch current.Type {
+			case ConstraintForeignKey:
+				if expected, ok := foreignKeys[strings.ToUpper(current.Name)]; ok {
+					reason = foreignKeyDrift(current, expected)
+				}
+			case ConstraintCheck:
+				for _, chk := range checks {
+					if strings.EqualFold(chk.Name, current.Name) && normalizeCheck(chk.Constraint) != normalizeCheck(current.Check) {
+						reason = "check " + current.Check + " -> " + chk.Constraint
+					}
+				}
+			}
+			// 在父类的AutoMigrate之后执行，删除后立即重新创建，缩短表上没有该约束的时间
+			if reason != "" {
+				changed := m.because("constraint %s changed: %s", current.Name, reason)
+				if err := changed.DropConstraint(value, current.Name); err != nil {
+					return err
+				}
+				if err := changed.Migrator.CreateConstraint(value, current.Name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// foreignKeyDrift 比较外键的列、引用的表和列、ON DELETE / ON UPDATE，返回不一致的原因
+func foreignKeyDrift(current Constraint, expected *schema.Constraint) string {
+	dbNames := func(fields []*schema.Field) []string {
+		names := make([]string, len(fields))
+		for i, field := range fields {
+			names[i] = field.DBName
+		}
+		return names
+	}
+	action := func(action string) string {
+		if action = strings.ToUpper(strings.TrimSpace(action)); action == "" || action == "RESTRICT" {
+			return "NO ACTION"
+		}
+		return action
+	}
+
+	refTable := expected.ReferenceSchema.Table
+	refTable = refTable[strings.LastIndexByte(refTable, '.')+1:]
+	switch {
+	case !sameNames(current.Columns, dbNames(expected.ForeignKeys)):
+		return "columns " + strings.Join(current.Columns, ",") + " -> " + strings.Join(dbNames(expected.ForeignKeys), ",")
+	case !strings.EqualFold(current.RefTable, refTable):
+		return "references " + current.RefTable + " -> " + refTable
+	case !sameNames(current.RefColumns, dbNames(expected.References)):
+		return "referenced columns " + strings.Join(current.RefColumns, ",") + " -> " + strings.Join(dbNames(expected.References), ",")
+	case current.OnDelete != action(expected.OnDelete):
+		return "on delete " + current.OnDelete + " -> " + action(expected.OnDelete)
+	case current.OnUpdate != action(expected.OnUpdate):
+		return "on update " + current.OnUpdate + " -> " + action(expected.OnUpdate)
+	}
+	return ""
+}
+
+// sameNames 按顺序、不区分大小写比较列名，复合外键的列与引用列一一对应
+func sameNames(a, b []string) bool {
+	return slices.EqualFunc(a, b, strings.EqualFold)
+}
+
+// normalizeCheck 去掉空白、引号和外层括号后比较CHECK条件，系统表中保存的条件格式可能与标签中不同
+func normalizeCheck(check string) string {
+	check = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', '"':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(check))
+	for enclosed(check) {
+		check = check[1 : len(check)-1]
+	}
+	return check
+}
+
+// enclosed 整个条件是否被一对括号包围
+func enclosed(check string) bool {
+	if !strings.HasPrefix(check, "(") || !strings.HasSuffix(check, ")") {
+		return false
+	}
+	depth := 0
+	for i, c := range check {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i < len(check)-1 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,356 @@
+package dm
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// maxConversionFailures 转换失败时最多报告的行数
+var maxConversionFailures = 100
+
+// ConversionFailure 无法转换为新类型的行
+type ConversionFailure struct {
+	RowID string
+	Value any
+}
+
+// ColumnConversionError 列类型转换时有数据无法转换，转换已撤销，列保持原类型
+type ColumnConversionError struct {
+	Table    string
+	Column   string
+	DataType string
+	Rows     []ConversionFailure
+}
+
+func (e *ColumnConversionError) Error() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "failed to convert column %s.%s to %s, %d rows cannot be converted", e.Table, e.Column, e.DataType, len(e.Rows))
+	for _, row := range e.Rows {
+		fmt.Fprintf(&builder, "\n\tROWID %s: %v", row.RowID, row.Value)
+	}
+	return builder.String()
+}
+
+var (
+	lobTypes       = []string{"CLOB", "TEXT", "LONGVARCHAR", "BLOB", "IMAGE", "LONGVARBINARY"}
+	characterTypes = []string{"CHAR", "CHARACTER", "VARCHAR", "VARCHAR2", "NVARCHAR2", "CLOB", "TEXT", "LONGVARCHAR"}
+	numericTypes   = []string{
+		"NUMBER", "NUMERIC", "DECIMAL", "DEC", "BIT", "TINYINT", "BYTE", "SMALLINT", "INT", "INTEGER", "BIGINT",
+		"FLOAT", "DOUBLE", "REAL", "BINARY_DOUBLE",
+	}
+	dateTypes      = []string{"DATE", "TIME", "TIMESTAMP", "DATETIME"}
+	convertedTypes = slices.Concat(numericTypes, dateTypes)
+
+	regTypeLength = regexp.MustCompile(`^[A-Za-z0-9_ ]+\((\d+)\)`)
+)
+
+// needsConversion DM不支持直接MODIFY的类型修改：大字段改为普通类型、字符串改为数值或时间、去掉自增
+func (m Migrator) needsConversion(field *schema.Field, columnType gorm.ColumnType) bool {
+	from := strings.ToUpper(columnType.DatabaseTypeName())
+	to := strings.ToUpper(baseTypeName(m.Dialector.DataTypeOf(field)))
+
+	switch {
+	case slices.Contains(lobTypes, from) && !slices.Contains(lobTypes, to):
+		return true
+	case slices.Contains(characterTypes, from) && slices.Contains(convertedTypes, to):
+		return true
+	}
+	if autoIncrement, ok := columnType.AutoIncrement(); ok && autoIncrement && !isIdentity(field) {
+		return true
+	}
+	return false
+}
+
+// convertColumn 通过临时列转换类型：添加临时列、转换数据、删除原列、临时列改名，最后补上NOT NULL、默认值等定义。
+// DDL会自动提交，出错时删除临时列，原列及数据不受影响；原列上的索引和约束随原列删除，转换后按原名称重新创建，
+// 转换后的列位于表的最后
+func (m Migrator) convertColumn(stmt *gorm.Statement, field *schema.Field) error {
+	var (
+		table    = m.CurrentTable(stmt)
+		column   = clause.Column{Name: field.DBName}
+		temp     = clause.Column{Name: field.DBName + "$TMP"}
+		dataType = castType(m.Dialector.DataTypeOf(field))
+	)
+	typeof := m.FullDataTypeOf(field)
+	var check string
+	typeof.SQL, check = splitCheck(typeof.SQL)
+
+	dependents, err := m.columnDependents(stmt, field, check)
+	if err != nil {
+		return err
+	}
+
+	convert := func(tx *gorm.DB) (err error) {
+		if err = tx.Exec("ALTER TABLE ? ADD ? "+dataType, table, temp).Error; err != nil {
+			return err
+		}
+		dropped := false
+		defer func() {
+			if err != nil && !dropped {
+				tx.Exec("ALTER TABLE ? DROP COLUMN ?", table, temp)
+			}
+		}()
+
+		if err = tx.Exec("UPDATE ? SET ? = CAST(? AS "+dataType+")", table, temp, column).Error; err != nil {
+			if failures := m.conversionFailures(tx, table, column, dataType); len(failures) > 0 {
+				return &ColumnConversionError{Table: stmt.Table, Column: field.DBName, DataType: dataType, Rows: failures}
+			}
+			return err
+		}
+		if err = tx.Exec("ALTER TABLE ? DROP COLUMN ? CASCADE", table, column).Error; err != nil {
+			return err
+		}
+		dropped = true
+		if err = tx.Exec("ALTER TABLE ? RENAME COLUMN ? TO ?", table, temp, column).Error; err != nil {
+			return err
+		}
+
+		// 原列上的唯一约束已随原列删除，需要完整的列定义；CHECK约束单独添加
+		if err = tx.Exec("ALTER TABLE ? MODIFY ? ?", table, column, typeof).Error; err != nil {
+			return err
+		}
+		if check != "" {
+			if err = tx.Exec("ALTER TABLE ? ADD "+check, table).Error; err != nil {
+				return err
+			}
+		}
+		for _, dependent := range dependents {
+			if err = tx.Exec(dependent.SQL, dependent.Vars...).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 生成迁移计划时只记录语句
+	if _, ok := m.DB.Statement.ConnPool.(*planConnPool); ok {
+		return convert(m.DB)
+	}
+	return m.DB.Transaction(convert)
+}
+
+// columnDependents 返回删除原列时会被级联删除的约束和索引的重建语句，先建主键和唯一约束，外键可能引用它们。
+// 列定义中已有的UNIQUE和CHECK由MODIFY和check添加，不再重建；其他表的外键引用该列时不能转换
+func (m Migrator) columnDependents(stmt *gorm.Statement, field *schema.Field, check string) ([]clause.Expr, error) {
+	// 其他表上引用该列所在主键或唯一约束的外键
+	referencedSql := `SELECT /*+ MAX_OPT_N_TABLES(5) */ DISTINCT CON_OBJ.NAME FROM
+(SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) SCHS,
+(SELECT ID, SCHID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ = 'UTAB' AND NAME = ?) TABS,
+(SELECT ID, COLID FROM SYS.SYSCOLUMNS WHERE NAME = ?) COLS,
+(SELECT ID, PID FROM SYS.SYSOBJECTS WHERE SUBTYPE$ = 'INDEX') OBJ_INDS, SYS.SYSINDEXES INDS, SYS.SYSCONS CONS,
+(SELECT ID, NAME FROM SYS.SYSOBJECTS WHERE SUBTYPE$ = 'CONS') CON_OBJ
+WHERE TABS.SCHID = SCHS.ID AND COLS.ID = TABS.ID AND OBJ_INDS.PID = TABS.ID AND INDS.ID = OBJ_INDS.ID
+AND SF_COL_IS_IDX_KEY(INDS.KEYNUM, INDS.KEYINFO, COLS.COLID) = 1
+AND CONS.TYPE$ = 'F' AND CONS.FINDEXID = INDS.ID AND CONS.TABLEID <> TABS.ID AND CON_OBJ.ID = CONS.ID`
+	// 包含该列、不属于约束的索引和全文索引，列按在索引中的顺序排列
+	indexSql := `WITH SCHS(ID) AS (SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?),
+TABS(ID, SCHID) AS (SELECT ID, SCHID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ = 'UTAB' AND NAME = ?)
+SELECT /*+ MAX_OPT_N_TABLES(5) */ OBJ_INDS.NAME, CASE INDS.ISUNIQUE WHEN 'Y' THEN 'UNIQUE' ELSE '' END, KEYS.NAME,
+SF_GET_INDEX_KEY_SEQ(INDS.KEYNUM, INDS.KEYINFO, KEYS.COLID) FROM SCHS, TABS,
+(SELECT ID, PID, NAME FROM SYS.SYSOBJECTS WHERE SUBTYPE$ = 'INDEX') OBJ_INDS, SYS.SYSINDEXES INDS, SYS.SYSCOLUMNS COLS, SYS.SYSCOLUMNS KEYS
+WHERE TABS.SCHID = SCHS.ID AND OBJ_INDS.PID = TABS.ID AND INDS.ID = OBJ_INDS.ID
+AND COLS.ID = TABS.ID AND COLS.NAME = ? AND SF_COL_IS_IDX_KEY(INDS.KEYNUM, INDS.KEYINFO, COLS.COLID) = 1
+AND KEYS.ID = TABS.ID AND SF_COL_IS_IDX_KEY(INDS.KEYNUM, INDS.KEYINFO, KEYS.COLID) = 1
+AND NOT EXISTS (SELECT 1 FROM SYS.SYSCONS CONS WHERE CONS.INDEXID = INDS.ID)
+UNION ALL SELECT OBJ_INDS.NAME, 'CONTEXT', COLS.NAME, 0 FROM SCHS, TABS, SYSCONTEXTINDEXES OBJ_INDS, SYS.SYSCOLUMNS COLS
+WHERE TABS.SCHID = SCHS.ID AND OBJ_INDS.TABLEID = TABS.ID AND COLS.ID = TABS.ID AND COLS.COLID = OBJ_INDS.COLID AND COLS.NAME = ?
+ORDER BY 1, 4`
+
+	var (
+		schemaName, tableName = m.tableSchema(stmt)
+		columnName            = m.identifier(field.DBName)
+		table                 = m.CurrentTable(stmt)
+		dependents            []clause.Expr
+	)
+
+	var referenced []string
+	if err := m.DB.Raw(referencedSql, schemaName, tableName, columnName).Scan(&referenced).Error; err != nil {
+		return nil, err
+	} else if len(referenced) > 0 {
+		return nil, fmt.Errorf("column %s.%s is referenced by foreign key %s, drop it before converting the column type",
+			stmt.Table, field.DBName, strings.Join(referenced, ", "))
+	}
+
+	// 按模式限定的表名查询，模型的表名中可能指定了其他模式
+	constraints, err := m.GetConstraints(schemaName + "." + tableName)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(constraints, func(a, b Constraint) int {
+		order := func(t ConstraintType) int { return strings.Index("PUFC", string(t)) }
+		return cmp.Compare(order(a.Type), order(b.Type))
+	})
+	for _, constraint := range constraints {
+		var definition clause.Expr
+		switch constraint.Type {
+		case ConstraintPrimaryKey, ConstraintUnique, ConstraintForeignKey:
+			if !slices.ContainsFunc(constraint.Columns, func(name string) bool { return strings.EqualFold(name, field.DBName) }) {
+				continue
+			}
+			if constraint.Type == ConstraintUnique && field.Unique && len(constraint.Columns) == 1 {
+				continue
+			}
+			definition = constraintDefinition(constraint)
+		case ConstraintCheck:
+			if !referencesColumn(constraint.Check, field.DBName) ||
+				check != "" && normalizeCheck(constraint.Check) == normalizeCheck(strings.TrimPrefix(check, "CHECK ")) {
+				continue
+			}
+			definition = constraintDefinition(constraint)
+		default:
+			continue
+		}
+		dependents = append(dependents, clause.Expr{
+			SQL:  "ALTER TABLE ? ADD CONSTRAINT ? " + definition.SQL,
+			Vars: append([]any{table, clause.Column{Name: constraint.Name}}, definition.Vars...),
+		})
+	}
+
+	rows, err := m.DB.Raw(indexSql, schemaName, tableName, columnName, columnName).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var (
+		indexes []string
+		kinds   = make(map[string]string)
+		columns = make(map[string][]any)
+	)
+	for rows.Next() {
+		var (
+			name, kind, column string
+			seq                int
+		)
+		if err := rows.Scan(&name, &kind, &column, &seq); err != nil {
+			return nil, err
+		}
+		if _, ok := kinds[name]; !ok {
+			indexes = append(indexes, name)
+			kinds[name] = kind
+		}
+		columns[name] = append(columns[name], clause.Column{Name: column})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, name := range indexes {
+		createIndexSQL := "CREATE "
+		if kinds[name] != "" {
+			createIndexSQL += kinds[name] + " "
+		}
+		dependents = append(dependents, clause.Expr{
+			SQL:  createIndexSQL + "INDEX ? ON ??",
+			Vars: []any{m.qualifiedName(stmt, name), table, columns[name]},
+		})
+	}
+	return dependents, nil
+}
+
+// constraintDefinition 按GetConstraints的结果生成约束定义
+func constraintDefinition(constraint Constraint) clause.Expr {
+	columns := func(names []string) []any {
+		result := make([]any, len(names))
+		for i, name := range names {
+			result[i] = clause.Column{Name: name}
+		}
+		return result
+	}
+
+	switch constraint.Type {
+	case ConstraintPrimaryKey:
+		return clause.Expr{SQL: "PRIMARY KEY ?", Vars: []any{columns(constraint.Columns)}}
+	case ConstraintUnique:
+		return clause.Expr{SQL: "UNIQUE ?", Vars: []any{columns(constraint.Columns)}}
+	case ConstraintForeignKey:
+		sql := "FOREIGN KEY ? REFERENCES ??"
+		if constraint.OnDelete != "" && constraint.OnDelete != "NO ACTION" {
+			sql += " ON DELETE " + constraint.OnDelete
+		}
+		if constraint.OnUpdate != "" && constraint.OnUpdate != "NO ACTION" {
+			sql += " ON UPDATE " + constraint.OnUpdate
+		}
+		return clause.Expr{SQL: sql, Vars: []any{
+			columns(constraint.Columns), clause.Table{Name: constraint.RefSchema + "." + constraint.RefTable}, columns(constraint.RefColumns),
+		}}
+	}
+	return clause.Expr{SQL: "CHECK (" + constraint.Check + ")"}
+}
+
+// referencesColumn CHECK条件中是否使用了该列，列名可能带引号，不区分大小写
+func referencesColumn(check, column string) bool {
+	return regexp.MustCompile(`(?i)(^|[^\w$#])"?` + regexp.QuoteMeta(column) + `"?($|[^\w$#])`).MatchString(check)
+}
+
+// conversionFailures 找出无法转换的行，最多maxConversionFailures行。
+// DM没有TRY_CAST，按目标类型检查长度、数值和日期格式，无法判断时返回nil，由调用方返回转换时的错误
+func (m Migrator) conversionFailures(tx *gorm.DB, table any, column clause.Column, dataType string) []ConversionFailure {
+	probe, ok := conversionProbe(column, dataType)
+	if !ok {
+		return nil
+	}
+
+	rows, err := tx.Raw("SELECT ROWID, ? FROM ? WHERE ? IS NOT NULL AND ? LIMIT ?", column, table, column, probe, maxConversionFailures).Rows()
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var failures []ConversionFailure
+	for rows.Next() {
+		var failure ConversionFailure
+		if err := rows.Scan(&failure.RowID, &failure.Value); err != nil {
+			break
+		}
+		failures = append(failures, failure)
+	}
+	return failures
+}
+
+// conversionProbe 值无法转换为dataType的条件：转为数值或日期时格式不正确，转为有长度的类型时超长
+func conversionProbe(column clause.Column, dataType string) (clause.Expr, bool) {
+	switch to := strings.ToUpper(baseTypeName(dataType)); {
+	case slices.Contains(numericTypes, to):
+		return clause.Expr{SQL: "ISNUMERIC(?) = 0", Vars: []any{column}}, true
+	case slices.Contains(dateTypes, to):
+		return clause.Expr{SQL: "ISDATE(?) = 0", Vars: []any{column}}, true
+	}
+	if matches := regTypeLength.FindStringSubmatch(dataType); matches != nil {
+		length, _ := strconv.Atoi(matches[1])
+		return clause.Expr{SQL: "LENGTHB(?) > ?", Vars: []any{column, length}}, true
+	}
+	return clause.Expr{}, false
+}
+
+// baseTypeName 类型名，不含长度、精度等
+func baseTypeName(sqlType string) string {
+	if idx := strings.IndexAny(sqlType, "( "); idx >= 0 {
+		return sqlType[:idx]
+	}
+	return sqlType
+}
+
+// splitCheck 拆分列定义中的CHECK约束（JSON列的 IS JSON 检查），MODIFY时不能再次添加
+func splitCheck(sqlType string) (string, string) {
+	if idx := strings.Index(sqlType, " CHECK "); idx >= 0 {
+		return sqlType[:idx], sqlType[idx+1:]
+	}
+	return sqlType, ""
+}
+
+// castType 去掉自增和CHECK约束，得到可用于CAST和添加临时列的类型
+func castType(sqlType string) string {
+	for _, suffix := range []string{" IDENTITY", " CHECK"} {
+		if idx := strings.Index(sqlType, suffix); idx >= 0 {
+			sqlType = sqlType[:idx]
+		}
+	}
+	return sqlType
+}
@@ -1,12 +1,19 @@
 package dm
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"reflect"
+	"slices"
+	"strings"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/callbacks"
 	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
 )
 
 func Create(db *gorm.DB) {
@@ -20,195 +27,849 @@ func Create(db *gorm.DB) {
 		}
 	}
 
-	var onConflict clause.OnConflict
-	var hasConflict bool
+	var (
+		onConflict  clause.OnConflict
+		hasConflict bool
+		returning   [][]any // RETURNING INTO 的输出参数，与各行一一对应，无需回填的行为nil
+		rowCount    func() int64
+	)
 	if db.Statement.SQL.String() == "" {
 		var (
 			values = callbacks.ConvertToCreateValues(db.Statement)
 			c      = db.Statement.Clauses["ON CONFLICT"]
 		)
+		applySequences(db.Statement, &values)
 		onConflict, hasConflict = c.Expression.(clause.OnConflict)
 
 		if hasConflict {
-			if len(db.Statement.Schema.PrimaryFields) > 0 {
+			if db.Statement.Schema == nil {
+				hasConflict = false
+			} else {
+				columns, err := conflictColumns(db.Statement, onConflict)
+				if db.AddError(err) != nil {
+					return
+				}
+				onConflict.Columns = columns
+
+				// 冲突列都有值时才能用MERGE INTO的ON条件判断冲突
 				columnsMap := map[string]bool{}
 				for _, column := range values.Columns {
 					columnsMap[column.Name] = true
 				}
 
-				for _, field := range db.Statement.Schema.PrimaryFields {
-					if _, ok := columnsMap[field.DBName]; !ok {
+				hasConflict = len(columns) > 0
+				for _, column := range columns {
+					if _, ok := columnsMap[column.Name]; !ok {
 						hasConflict = false
 					}
 				}
-			} else {
-				hasConflict = false
 			}
 		}
 
-		if hasConflict {
-			MergeCreate(db, onConflict, values)
-		} else {
+		if !hasConflict {
 			setIdentityInsert := false
 
 			if db.Statement.Schema != nil {
-				if field := db.Statement.Schema.PrioritizedPrimaryField; field != nil && field.AutoIncrement {
+				if field := db.Statement.Schema.PrioritizedPrimaryField; field != nil && isIdentity(field) {
 					switch db.Statement.ReflectValue.Kind() {
 					case reflect.Struct:
 						_, isZero := field.ValueOf(db.Statement.Context, db.Statement.ReflectValue)
 						setIdentityInsert = !isZero
 					case reflect.Slice, reflect.Array:
-						for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
+						for i := 0; i < db.Statement.ReflectValue.Len() && !setIdentityInsert; i++ {
 							obj := db.Statement.ReflectValue.Index(i)
 							if reflect.Indirect(obj).Kind() == reflect.Struct {
-								_, isZero := field.ValueOf(db.Statement.Context, db.Statement.ReflectValue.Index(i))
+								_, isZero := field.ValueOf(db.Statement.Context, obj)
 								setIdentityInsert = !isZero
 							}
 						}
 					}
 
 					if setIdentityInsert && !db.DryRun && db.Error == nil {
-						db.Statement.SQL.Reset()
-						db.Statement.WriteString("SET IDENTITY_INSERT ")
-						db.Statement.WriteQuoted(db.Statement.Table)
-						db.Statement.WriteString(" ON;")
-						_, err := db.Statement.ConnPool.ExecContext(db.Statement.Context, db.Statement.SQL.String(), db.Statement.Vars...)
+						restore, err := identityInsertOn(db)
 						if db.AddError(err) != nil {
 							return
 						}
-						defer func() {
-							db.Statement.SQL.Reset()
-							db.Statement.WriteString("SET IDENTITY_INSERT ")
-							db.Statement.WriteQuoted(db.Statement.Table)
-							db.Statement.WriteString(" OFF;")
-							db.Statement.ConnPool.ExecContext(db.Statement.Context, db.Statement.SQL.String(), db.Statement.Vars...)
-						}()
+						defer restore()
 					}
 				}
 			}
 
 			db.Statement.SQL.Reset()
 			db.Statement.AddClauseIfNotExists(clause.Insert{})
-			db.Statement.Build("INSERT")
-			db.Statement.WriteByte(' ')
+		}
 
-			db.Statement.AddClause(values)
-			if values, ok := db.Statement.Clauses["VALUES"].Expression.(clause.Values); ok {
-				if len(values.Columns) > 0 {
-					db.Statement.WriteByte('(')
-					for idx, column := range values.Columns {
-						if idx > 0 {
-							db.Statement.WriteByte(',')
-						}
-						db.Statement.WriteQuoted(column)
-					}
-					db.Statement.WriteByte(')')
+		if !hasConflict && len(values.Values) > 1 && arrayBindInsert(db) && !needsReturning(db.Statement, len(values.Values)) {
+			if rows, ok := arrayBindRows(values); ok {
+				createArrayBind(db, values, rows)
+				return
+			}
+		}
+
+		// 行数较多时按参数个数和消息长度上限分批执行，DryRun时只生成一条语句；合并时每批还有一个取回MERGE影响行数的输出参数
+		maxParams := maxBatchParams
+		if hasConflict {
+			maxParams--
+		}
+		if chunks := chunkRows(values, paramsPerRow(db.Statement, values, onConflict, hasConflict), maxParams, maxBatchBytes); len(chunks) > 1 && !db.DryRun {
+			createInChunks(db, onConflict, hasConflict, values, chunks)
+			return
+		}
 
-					//outputInserted(db)
+		returning, rowCount = buildCreate(db, onConflict, hasConflict, values)
+	}
 
-					db.Statement.WriteString(" VALUES ")
+	if !db.DryRun && db.Error == nil {
+		db.RowsAffected = execCreate(db, returning, rowCount)
+	}
+}
 
-					for idx, value := range values.Values {
-						if idx > 0 {
-							db.Statement.WriteByte(',')
-						}
+// identityInsertOn 开启表的IDENTITY_INSERT，返回关闭并恢复连接的函数。
+// IDENTITY_INSERT 是会话级设置，不在事务中时需要固定一个连接，保证插入和开关在同一个会话中执行，
+// 无论插入是否成功都会关闭，避免设置遗留到连接池中的其他会话
+func identityInsertOn(db *gorm.DB) (func(), error) {
+	var (
+		ctx      = db.Statement.Context
+		connPool = db.Statement.ConnPool
+		conn     *sql.Conn
+	)
+	if connector, ok := connPool.(interface {
+		Conn(context.Context) (*sql.Conn, error)
+	}); ok {
+		var err error
+		if conn, err = connector.Conn(ctx); err != nil {
+			return nil, err
+		}
+		db.Statement.ConnPool = conn
+	}
 
-						db.Statement.WriteByte('(')
-						db.Statement.AddVar(db.Statement, value...)
-						db.Statement.WriteByte(')')
-					}
+	restore := func() {
+		_, err := db.Statement.ConnPool.ExecContext(ctx, identityInsertSQL(db.Statement, false))
+		if conn != nil {
+			if err != nil {
+				// 无法确认连接上的设置已关闭，丢弃该连接
+				_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			}
+			_ = conn.Close()
+			db.Statement.ConnPool = connPool
+		}
+		_ = db.AddError(err)
+	}
+
+	if _, err := db.Statement.ConnPool.ExecContext(ctx, identityInsertSQL(db.Statement, true)); err != nil {
+		if conn != nil {
+			_ = conn.Close()
+			db.Statement.ConnPool = connPool
+		}
+		return nil, err
+	}
+	return restore, nil
+}
+
+func identityInsertSQL(stmt *gorm.Statement, on bool) string {
+	table := stmt.Quote(stmt.Table)
+	if stmt.TableExpr != nil && len(stmt.TableExpr.Vars) == 0 {
+		table = stmt.TableExpr.SQL
+	}
+	if on {
+		return "SET IDENTITY_INSERT " + table + " ON;"
+	}
+	return "SET IDENTITY_INSERT " + table + " OFF;"
+}
+
+// buildCreate 构造插入或合并语句，返回需要回填的输出参数。
+// 语句为匿名块时同时返回执行后获取影响行数的函数，其他语句返回nil，使用驱动返回的影响行数
+func buildCreate(db *gorm.DB, onConflict clause.OnConflict, hasConflict bool, values clause.Values) ([][]any, func() int64) {
+	if hasConflict {
+		return mergeCreate(db, onConflict, values)
+	}
+
+	db.Statement.AddClause(values)
+	if values, ok := db.Statement.Clauses["VALUES"].Expression.(clause.Values); ok {
+		returning := buildInsert(db, values)
+		if len(returning) > 1 {
+			// 块内每一行都已插入
+			return returning, func() int64 { return int64(len(returning)) }
+		}
+		return returning, nil
+	}
+	return nil, nil
+}
+
+// execCreate 执行构造好的语句并回填生成的值，返回影响行数
+func execCreate(db *gorm.DB, returning [][]any, rowCount func() int64) int64 {
+	result, err := db.Statement.ConnPool.ExecContext(db.Statement.Context, db.Statement.SQL.String(), db.Statement.Vars...)
+	if db.AddError(err) != nil {
+		return 0
+	}
+
+	var rowsAffected int64
+	if rowCount != nil {
+		// 匿名块返回的影响行数不可靠
+		rowsAffected = rowCount()
+	} else {
+		rowsAffected, _ = result.RowsAffected()
+	}
+
+	if returning != nil {
+		backfillReturning(db, returning)
+	}
+	return rowsAffected
+}
+
+var (
+	// maxBatchParams 一条语句的参数个数上限，与驱动的 PARAM_COUNT_LIMIT 一致
+	maxBatchParams = int(PARAM_COUNT_LIMIT)
+	// maxBatchBytes 参数数据的长度上限，驱动的消息长度上限为 Dm_build_977，留出一半给SQL文本和协议开销
+	maxBatchBytes = Dm_build_977 / 2
+)
+
+const (
+	// ArrayBindInsertKey 通过 db.Set(ArrayBindInsertKey, true) 为单次Create开启数组绑定插入
+	ArrayBindInsertKey = "dm:array_bind_insert"
+	// BatchErrorsKey 数组绑定插入时部分行失败（DSN中设置了continueBatchOnError）的错误，通过 result.Get(BatchErrorsKey) 获取
+	BatchErrorsKey = "dm:batch_errors"
+)
+
+func arrayBindInsert(db *gorm.DB) bool {
+	if v, ok := db.Get(ArrayBindInsertKey); ok {
+		enabled, _ := v.(bool)
+		return enabled
+	}
+	if dialector, ok := db.Dialector.(*Dialector); ok && dialector.Config != nil {
+		return dialector.ArrayBindInsert
+	}
+	return false
+}
+
+// needsReturning 数组绑定无法取回数据库生成的值：有行的主键或序列字段需要回填，
+// 或者有依赖主键的关联需要保存时，使用普通的 RETURNING INTO 插入
+func needsReturning(stmt *gorm.Statement, rows int) bool {
+	if fields := returningFields(stmt); len(fields) > 0 {
+		for idx := 0; idx < rows; idx++ {
+			if hasZeroField(stmt, idx, fields) {
+				return true
+			}
+		}
+	}
+	if stmt.Schema == nil {
+		return false
+	}
+
+	selectColumns, restricted := stmt.SelectAndOmitColumns(true, false)
+	for _, rel := range stmt.Schema.Relationships.Relations {
+		if rel.Type == schema.BelongsTo {
+			continue
+		}
+		if v, ok := selectColumns[rel.Name]; (ok && !v) || (!ok && restricted) {
+			continue
+		}
+		return true
+	}
+	return false
+}
 
-					db.Statement.WriteString(";")
+// arrayBindRows 将各行转为驱动数组绑定需要的值，有表达式（如序列的NEXTVAL）时不能使用数组绑定
+func arrayBindRows(values clause.Values) ([][]any, bool) {
+	if len(values.Columns) == 0 {
+		return nil, false
+	}
+
+	rows := make([][]any, len(values.Values))
+	for idx, value := range values.Values {
+		row := make([]any, len(value))
+		for i, v := range value {
+			if _, ok := v.(clause.Expression); ok {
+				return nil, false
+			}
+			// 数组中的值不经过database/sql的转换，需要先取出Valuer和指针的值
+			if valuer, ok := v.(driver.Valuer); ok {
+				var err error
+				if v, err = valuer.Value(); err != nil {
+					return nil, false
+				}
+			}
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+				if rv.IsNil() {
+					v = nil
 				} else {
-					db.Statement.WriteString("DEFAULT VALUES;")
+					v = rv.Elem().Interface()
 				}
 			}
+			row[i] = v
 		}
+		rows[idx] = row
 	}
+	return rows, true
+}
 
-	if !db.DryRun && db.Error == nil {
-		var (
-			rows           *sql.Rows
-			result         sql.Result
-			err            error
-			updateInsertID bool  // 是否需要更新主键自增列
-			insertID       int64 // 主键自增列最新值
-		)
-		if hasConflict {
-			rows, err = db.Statement.ConnPool.QueryContext(db.Statement.Context, db.Statement.SQL.String(), db.Statement.Vars...)
-			if db.AddError(err) != nil {
-				return
-			}
-			defer rows.Close()
-			if rows.Next() {
-				err = rows.Scan(&insertID)
-				if err != nil {
-					return
+// createArrayBind 预编译单行INSERT，按消息长度上限分批以数组绑定执行
+func createArrayBind(db *gorm.DB, values clause.Values, rows [][]any) {
+	db.Statement.SQL.Reset()
+	db.Statement.Build("INSERT")
+	db.Statement.WriteByte(' ')
+	writeInsertColumns(db.Statement, values.Columns)
+	db.Statement.WriteString(" VALUES (")
+	for idx := range values.Columns {
+		if idx > 0 {
+			db.Statement.WriteByte(',')
+		}
+		db.Statement.WriteByte('?')
+	}
+	db.Statement.WriteByte(')')
+	db.Statement.Vars = []any{rows}
+
+	if db.DryRun || db.Error != nil {
+		return
+	}
+
+	var batchErrors []error
+	for _, chunk := range chunkRows(values, 0, maxBatchParams, maxBatchBytes) {
+		batch := rows[chunk[0]:chunk[1]]
+		result, err := db.Statement.ConnPool.ExecContext(db.Statement.Context, db.Statement.SQL.String(), batch)
+
+		var dmErr *DmError
+		if errors.As(err, &dmErr) && dmErr.ErrCode == EC_BP_WITH_ERROR.ErrCode && len(dmErr.updateCounts) == len(batch) {
+			// continueBatchOnError 时失败的行不影响其他行，按驱动返回的每行执行结果累计成功插入的行数
+			for _, count := range dmErr.updateCounts {
+				if count > 0 {
+					db.RowsAffected += count
 				}
-				if insertID > 0 {
-					updateInsertID = true
+			}
+			batchErrors = append(batchErrors, err)
+			continue
+		}
+		if db.AddError(err) != nil {
+			return
+		}
+		rowsAffected, _ := result.RowsAffected()
+		db.RowsAffected += rowsAffected
+	}
+
+	if len(batchErrors) > 0 {
+		db.Statement.Settings.Store(BatchErrorsKey, batchErrors)
+		db.Logger.Warn(db.Statement.Context, "%d batch(es) inserted with failed rows: %v", len(batchErrors), batchErrors)
+	}
+}
+
+// paramsPerRow 每行最多占用的参数个数：列值、合并后查询回填值的条件和 RETURNING INTO 的输出参数
+func paramsPerRow(stmt *gorm.Statement, values clause.Values, onConflict clause.OnConflict, hasConflict bool) int {
+	count := len(values.Columns) + len(returningFields(stmt))
+	if hasConflict {
+		count += len(onConflict.Columns)
+	}
+	return count
+}
+
+// chunkRows 按参数个数和参数数据长度将行拆分为多批，返回每批的起止下标
+func chunkRows(values clause.Values, paramsPerRow, maxParams, maxBytes int) [][2]int {
+	var (
+		chunks [][2]int
+		start  int
+		params int
+		bytes  int
+	)
+	for idx, row := range values.Values {
+		size := 0
+		for _, value := range row {
+			switch v := value.(type) {
+			case string:
+				size += len(v)
+			case []byte:
+				size += len(v)
+			default:
+				size += 16
+			}
+		}
+
+		if idx > start && (params+paramsPerRow > maxParams || bytes+size > maxBytes) {
+			chunks = append(chunks, [2]int{start, idx})
+			start, params, bytes = idx, 0, 0
+		}
+		params += paramsPerRow
+		bytes += size
+	}
+	return append(chunks, [2]int{start, len(values.Values)})
+}
+
+// createInChunks 逐批构造并执行，每批只对自己的行回填，影响行数累加
+func createInChunks(db *gorm.DB, onConflict clause.OnConflict, hasConflict bool, values clause.Values, chunks [][2]int) {
+	reflectValue := db.Statement.ReflectValue
+	defer func() {
+		db.Statement.ReflectValue = reflectValue
+	}()
+
+	var rowsAffected int64
+	for _, chunk := range chunks {
+		db.Statement.SQL.Reset()
+		db.Statement.Vars = nil
+		// 回填按行下标定位结构体，截取与本批对应的切片，元素与原切片共享
+		db.Statement.ReflectValue = reflectValue.Slice(chunk[0], chunk[1])
+
+		returning, rowCount := buildCreate(db, onConflict, hasConflict, clause.Values{Columns: values.Columns, Values: values.Values[chunk[0]:chunk[1]]})
+		if db.Error != nil {
+			break
+		}
+		rowsAffected += execCreate(db, returning, rowCount)
+		if db.Error != nil {
+			break
+		}
+	}
+	db.RowsAffected = rowsAffected
+}
+
+// buildInsert 构造INSERT语句。需要回填主键时，每一行都通过 RETURNING ... INTO 取回实际生成的主键值，
+// 多行时放在同一个匿名块中一次执行，返回与各行一一对应的输出参数；否则返回nil
+func buildInsert(db *gorm.DB, values clause.Values) [][]any {
+	fields := returningFields(db.Statement)
+	if len(fields) == 0 {
+		db.Statement.Build("INSERT")
+		db.Statement.WriteByte(' ')
+
+		if len(values.Columns) > 0 {
+			writeInsertColumns(db.Statement, values.Columns)
+
+			//outputInserted(db)
+
+			db.Statement.WriteString(" VALUES ")
+
+			for idx, value := range values.Values {
+				if idx > 0 {
+					db.Statement.WriteByte(',')
 				}
+
+				db.Statement.WriteByte('(')
+				db.Statement.AddVar(db.Statement, value...)
+				db.Statement.WriteByte(')')
 			}
+
+			db.Statement.WriteString(";")
 		} else {
-			result, err = db.Statement.ConnPool.ExecContext(db.Statement.Context, db.Statement.SQL.String(), db.Statement.Vars...)
-			if db.AddError(err) != nil {
-				return
+			db.Statement.WriteString("DEFAULT VALUES;")
+		}
+		return nil
+	}
+
+	var (
+		returning = make([][]any, len(values.Values))
+		isBlock   = len(values.Values) > 1
+	)
+	if isBlock {
+		db.Statement.WriteString("BEGIN ")
+	}
+	for idx, value := range values.Values {
+		db.Statement.Build("INSERT")
+		db.Statement.WriteByte(' ')
+		if len(values.Columns) > 0 {
+			writeInsertColumns(db.Statement, values.Columns)
+			db.Statement.WriteString(" VALUES (")
+			db.Statement.AddVar(db.Statement, value...)
+			db.Statement.WriteByte(')')
+		} else {
+			db.Statement.WriteString("DEFAULT VALUES")
+		}
+		returning[idx] = writeReturning(db.Statement, fields)
+		db.Statement.WriteString(";")
+		if isBlock {
+			db.Statement.WriteByte(' ')
+		}
+	}
+	if isBlock {
+		db.Statement.WriteString("END;")
+	}
+	return returning
+}
+
+func writeInsertColumns(stmt *gorm.Statement, columns []clause.Column) {
+	stmt.WriteByte('(')
+	for idx, column := range columns {
+		if idx > 0 {
+			stmt.WriteByte(',')
+		}
+		stmt.WriteQuoted(column)
+	}
+	stmt.WriteByte(')')
+}
+
+// writeReturning 写入 RETURNING c1,c2 INTO ?,?，返回绑定的输出参数
+func writeReturning(stmt *gorm.Statement, fields []*schema.Field) []any {
+	stmt.WriteString(" RETURNING ")
+	writeReturningColumns(stmt, fields)
+	return writeInto(stmt, fields)
+}
+
+func writeReturningColumns(stmt *gorm.Statement, fields []*schema.Field) {
+	for idx, field := range fields {
+		if idx > 0 {
+			stmt.WriteByte(',')
+		}
+		stmt.WriteQuoted(field.DBName)
+	}
+}
+
+func writeInto(stmt *gorm.Statement, fields []*schema.Field) []any {
+	dests := make([]any, len(fields))
+	stmt.WriteString(" INTO ")
+	for idx, field := range fields {
+		if idx > 0 {
+			stmt.WriteByte(',')
+		}
+		dests[idx] = newReturningDest(field)
+		stmt.AddVar(stmt, sql.Out{Dest: dests[idx]})
+	}
+	return dests
+}
+
+// returningFields 返回插入后需要回填的字段：由数据库生成的主键和使用序列的字段。
+// 不需要回填或无法回填时返回nil
+func returningFields(stmt *gorm.Statement) []*schema.Field {
+	if stmt.Schema == nil {
+		return nil
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct:
+	case reflect.Slice, reflect.Array:
+		elem := stmt.ReflectValue.Type().Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	var fields []*schema.Field
+	if field := stmt.Schema.PrioritizedPrimaryField; field != nil && field.DBName != "" &&
+		(field.HasDefaultValue || sequenceOf(field) != "") && newReturningDest(field) != nil {
+		fields = append(fields, field)
+	}
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName != "" && field != stmt.Schema.PrioritizedPrimaryField &&
+			sequenceOf(field) != "" && newReturningDest(field) != nil {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// newReturningDest 按字段类型创建输出参数，不支持的类型返回nil
+func newReturningDest(field *schema.Field) any {
+	switch field.DataType {
+	case schema.Int, schema.Uint:
+		return new(sql.NullInt64)
+	case schema.String:
+		return new(sql.NullString)
+	}
+	return nil
+}
+
+// rowValue 返回第idx行对应的结构体值
+func rowValue(stmt *gorm.Statement, idx int) reflect.Value {
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		return stmt.ReflectValue
+	}
+	return stmt.ReflectValue.Index(idx)
+}
+
+// hasZeroField 第idx行中是否有字段仍为零值
+func hasZeroField(stmt *gorm.Statement, idx int, fields []*schema.Field) bool {
+	for _, field := range fields {
+		if _, isZero := field.ValueOf(stmt.Context, rowValue(stmt, idx)); isZero {
+			return true
+		}
+	}
+	return false
+}
+
+// applySequences 将使用序列且值为零的字段替换为 序列.NEXTVAL，由returningFields回填生成的值
+func applySequences(stmt *gorm.Statement, values *clause.Values) {
+	if stmt.Schema == nil || len(values.Values) == 0 {
+		return
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+	default:
+		return
+	}
+
+	for _, field := range stmt.Schema.Fields {
+		seq := sequenceOf(field)
+		if seq == "" || field.DBName == "" {
+			continue
+		}
+
+		index := -1
+		for idx, column := range values.Columns {
+			if column.Name == field.DBName {
+				index = idx
 			}
-			db.RowsAffected, _ = result.RowsAffected()
-			if db.RowsAffected != 0 && db.Statement.Schema != nil &&
-				db.Statement.Schema.PrioritizedPrimaryField != nil &&
-				db.Statement.Schema.PrioritizedPrimaryField.HasDefaultValue {
-				insertID, err = result.LastInsertId()
-				insertOk := err == nil && insertID > 0
-				if !insertOk {
-					_ = db.AddError(err)
-					return
+		}
+		if index < 0 {
+			index = len(values.Columns)
+			values.Columns = append(values.Columns, clause.Column{Name: field.DBName})
+			for idx := range values.Values {
+				values.Values[idx] = append(values.Values[idx], nil)
+			}
+		}
+
+		nextval := clause.Expr{SQL: "?.NEXTVAL", Vars: []any{clause.Table{Name: seq}}}
+		for idx := range values.Values {
+			// map创建时按map中的值判断，未指定或为零值时同样使用序列
+			if stmt.ReflectValue.Kind() == reflect.Map || reflect.Indirect(rowValue(stmt, idx)).Kind() != reflect.Struct {
+				if value := values.Values[idx][index]; isNullValue(value) || reflect.ValueOf(value).IsZero() {
+					values.Values[idx][index] = nextval
 				}
-				updateInsertID = true
+				continue
+			}
+			if value, isZero := field.ValueOf(stmt.Context, rowValue(stmt, idx)); isZero {
+				values.Values[idx][index] = nextval
+			} else {
+				values.Values[idx][index] = value
 			}
 		}
+	}
+}
 
-		if updateInsertID {
-			switch db.Statement.ReflectValue.Kind() {
-			case reflect.Slice, reflect.Array:
-				//if config.LastInsertIDReversed {
-				for i := db.Statement.ReflectValue.Len() - 1; i >= 0; i-- {
-					rv := db.Statement.ReflectValue.Index(i)
-					if reflect.Indirect(rv).Kind() != reflect.Struct {
-						break
-					}
+// backfillReturning 将取回的值写回对应的行，只回填仍为零值的字段
+func backfillReturning(db *gorm.DB, returning [][]any) {
+	fields := returningFields(db.Statement)
+	for idx, dests := range returning {
+		if dests == nil {
+			continue
+		}
 
-					_, isZero := db.Statement.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, rv)
-					if isZero {
-						_ = db.AddError(db.Statement.Schema.PrioritizedPrimaryField.Set(db.Statement.Context, rv, insertID))
-						insertID -= db.Statement.Schema.PrioritizedPrimaryField.AutoIncrementIncrement
-					}
+		rv := rowValue(db.Statement, idx)
+		if reflect.Indirect(rv).Kind() != reflect.Struct {
+			continue
+		}
+
+		for i, field := range fields {
+			if _, isZero := field.ValueOf(db.Statement.Context, rv); !isZero {
+				continue
+			}
+
+			var value any
+			switch v := dests[i].(type) {
+			case *sql.NullInt64:
+				if !v.Valid {
+					continue
+				}
+				value = v.Int64
+			case *sql.NullString:
+				if !v.Valid {
+					continue
+				}
+				value = v.String
+			}
+			_ = db.AddError(field.Set(db.Statement.Context, rv, value))
+		}
+	}
+}
+
+// conflictColumns 返回ON CONFLICT的冲突列：优先使用OnConstraint指定的唯一索引或唯一约束，其次是Columns，默认为主键
+func conflictColumns(stmt *gorm.Statement, onConflict clause.OnConflict) ([]clause.Column, error) {
+	var columns []clause.Column
+	switch {
+	case onConflict.OnConstraint != "":
+		if idx := stmt.Schema.LookIndex(onConflict.OnConstraint); idx != nil && idx.Name == onConflict.OnConstraint {
+			if idx.Class != "UNIQUE" {
+				return nil, fmt.Errorf("index %s is not unique", onConflict.OnConstraint)
+			}
+			for _, option := range idx.Fields {
+				columns = append(columns, clause.Column{Name: option.DBName})
+			}
+		} else if uni, ok := stmt.Schema.ParseUniqueConstraints()[onConflict.OnConstraint]; ok {
+			columns = append(columns, clause.Column{Name: uni.Field.DBName})
+		} else {
+			return nil, fmt.Errorf("failed to look up unique constraint with name: %s", onConflict.OnConstraint)
+		}
+	case len(onConflict.Columns) > 0:
+		// 列名可以是字段名或列名，大写标识符模式下列名与用户写的大小写可能不同
+		for _, column := range onConflict.Columns {
+			field := stmt.Schema.LookUpField(column.Name)
+			for _, f := range stmt.Schema.Fields {
+				if field != nil {
+					break
+				}
+				if f.DBName != "" && strings.EqualFold(f.DBName, column.Name) {
+					field = f
+				}
+			}
+			if field == nil || field.DBName == "" {
+				return nil, fmt.Errorf("failed to look up conflict column with name: %s", column.Name)
+			}
+			columns = append(columns, clause.Column{Name: field.DBName})
+		}
+	default:
+		for _, field := range stmt.Schema.PrimaryFields {
+			columns = append(columns, clause.Column{Name: field.DBName})
+		}
+	}
+	return columns, nil
+}
+
+// MergeCreate 使用MERGE INTO实现ON CONFLICT，按onConflict.Columns（为空时按主键）判断冲突。
+// 需要回填主键时返回与各行对应的输出参数
+func MergeCreate(db *gorm.DB, onConflict clause.OnConflict, values clause.Values) [][]any {
+	returning, _ := mergeCreate(db, onConflict, values)
+	return returning
+}
+
+// mergeCreate 构造MERGE语句，需要回填主键时放在匿名块中，同时返回获取块内实际插入或更新行数的函数。
+// DoNothing或UPDATE条件不满足的行不计入影响行数，MERGE的行数在块内通过 SQL%ROWCOUNT 取回
+func mergeCreate(db *gorm.DB, onConflict clause.OnConflict, values clause.Values) ([][]any, func() int64) {
+	if len(onConflict.Columns) == 0 {
+		for _, field := range db.Statement.Schema.PrimaryFields {
+			onConflict.Columns = append(onConflict.Columns, clause.Column{Name: field.DBName})
+		}
+	}
+
+	fields := returningFields(db.Statement)
+	if len(fields) == 0 {
+		buildMerge(db, onConflict, values)
+		return nil, nil
+	}
+
+	// merge into 语句无法通过LastInsertID或RETURNING获取插入记录的主键：
+	// 冲突列包含主键时，主键为零值的行不会与已有记录冲突，直接 INSERT ... RETURNING INTO；
+	// 否则在MERGE之后按冲突列逐行查询
+	var (
+		returning   = make([][]any, len(values.Values))
+		mergeRows   = make([][]any, 0, len(values.Values))
+		insertRows  = make([]int, 0, len(values.Values))
+		selectRows  = make([]int, 0, len(values.Values))
+		keyIncluded = false
+		key         = db.Statement.Schema.PrioritizedPrimaryField
+	)
+	var conflictIndexes []int
+	for _, column := range onConflict.Columns {
+		keyIncluded = keyIncluded || fields[0] == key && column.Name == key.DBName
+		for i, c := range values.Columns {
+			if c.Name == column.Name {
+				conflictIndexes = append(conflictIndexes, i)
+			}
+		}
+	}
+	for idx, value := range values.Values {
+		switch {
+		case !hasZeroField(db.Statement, idx, fields):
+			mergeRows = append(mergeRows, value)
+		case keyIncluded && hasZeroField(db.Statement, idx, fields[:1]):
+			insertRows = append(insertRows, idx)
+		case slices.ContainsFunc(conflictIndexes, func(i int) bool { return isNullValue(value[i]) }):
+			// 冲突列为NULL时 ON 条件不成立，MERGE总是插入，之后也无法按冲突列查询到唯一的行
+			insertRows = append(insertRows, idx)
+		default:
+			mergeRows = append(mergeRows, value)
+			selectRows = append(selectRows, idx)
+		}
+	}
+
+	if len(insertRows) == 0 && len(selectRows) == 0 {
+		buildMerge(db, onConflict, values)
+		return nil, nil
+	}
+
+	merged := new(int64)
+	db.Statement.WriteString("BEGIN ")
+	if len(mergeRows) > 0 {
+		buildMerge(db, onConflict, clause.Values{Columns: values.Columns, Values: mergeRows})
+		db.Statement.WriteByte(' ')
+		db.Statement.AddVar(db.Statement, sql.Out{Dest: merged})
+		db.Statement.WriteString(" := SQL%ROWCOUNT; ")
+	}
+
+	columns, indexes := insertColumns(db.Statement, values.Columns)
+	for _, idx := range insertRows {
+		db.Statement.WriteString("INSERT INTO ")
+		db.Statement.WriteQuoted(db.Statement.Table)
+		db.Statement.WriteByte(' ')
+		if len(columns) > 0 {
+			writeInsertColumns(db.Statement, columns)
+			db.Statement.WriteString(" VALUES (")
+			for i, index := range indexes {
+				if i > 0 {
+					db.Statement.WriteByte(',')
 				}
-				//} else {
-				//	for i := 0; i < db.Statement.ReflectValue.Len(); i++ {
-				//		rv := db.Statement.ReflectValue.Index(i)
-				//		if reflect.Indirect(rv).Kind() != reflect.Struct {
-				//			break
-				//		}
-				//
-				//		if _, isZero := db.Statement.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, rv); isZero {
-				//			db.AddError(db.Statement.Schema.PrioritizedPrimaryField.Set(db.Statement.Context, rv, insertID))
-				//			insertID += db.Statement.Schema.PrioritizedPrimaryField.AutoIncrementIncrement
-				//		}
-				//	}
-				//}
-			case reflect.Struct:
-				_, isZero := db.Statement.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, db.Statement.ReflectValue)
-				if isZero {
-					_ = db.AddError(db.Statement.Schema.PrioritizedPrimaryField.Set(db.Statement.Context, db.Statement.ReflectValue, insertID))
+				db.Statement.AddVar(db.Statement, values.Values[idx][index])
+			}
+			db.Statement.WriteByte(')')
+		} else {
+			db.Statement.WriteString("DEFAULT VALUES")
+		}
+		returning[idx] = writeReturning(db.Statement, fields)
+		db.Statement.WriteString("; ")
+	}
+
+	for _, idx := range selectRows {
+		db.Statement.WriteString("SELECT ")
+		writeReturningColumns(db.Statement, fields)
+		returning[idx] = writeInto(db.Statement, fields)
+		db.Statement.WriteString(" FROM ")
+		db.Statement.WriteQuoted(db.Statement.Table)
+		db.Statement.WriteString(" WHERE ")
+
+		var where clause.Where
+		for _, column := range onConflict.Columns {
+			for i, c := range values.Columns {
+				if c.Name == column.Name {
+					where.Exprs = append(where.Exprs, clause.Eq{
+						Column: clause.Column{Table: db.Statement.Table, Name: column.Name},
+						Value:  values.Values[idx][i],
+					})
 				}
 			}
 		}
+		where.Build(db.Statement)
+		db.Statement.WriteString("; ")
+	}
+	db.Statement.WriteString("END;")
+
+	return returning, func() int64 { return *merged + int64(len(insertRows)) }
+}
+
+// isNullValue 值绑定后是否为NULL
+func isNullValue(value any) bool {
+	if rv := reflect.ValueOf(value); value == nil || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return true
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		return err == nil && v == nil
+	}
+	return false
+}
+
+// insertColumns 返回MERGE插入分支使用的列及其在values中的下标，IDENTITY自增主键由数据库生成，不参与插入
+func insertColumns(stmt *gorm.Statement, columns []clause.Column) ([]clause.Column, []int) {
+	var (
+		result  = make([]clause.Column, 0, len(columns))
+		indexes = make([]int, 0, len(columns))
+		key     = stmt.Schema.PrioritizedPrimaryField
+	)
+	for idx, column := range columns {
+		if key == nil || !isIdentity(key) || key.DBName != column.Name {
+			result = append(result, column)
+			indexes = append(indexes, idx)
+		}
 	}
+	return result, indexes
 }
 
-func MergeCreate(db *gorm.DB, onConflict clause.OnConflict, values clause.Values) {
-	db.Statement.WriteString("MERGE INTO ")
+// excludedTable MERGE中USING子查询的别名，与gorm的 clause.AssignmentColumns 使用的表名一致，
+// 声明和引用都经过QuoteTo，大写标识符模式下同样转为大写
+const excludedTable = "excluded"
+
+func buildMerge(db *gorm.DB, onConflict clause.OnConflict, values clause.Values) {
+	db.Statement.WriteString("MERGE ")
+	if c, ok := db.Statement.Clauses["MERGE"]; ok && c.AfterNameExpression != nil {
+		c.AfterNameExpression.Build(db.Statement)
+		db.Statement.WriteByte(' ')
+	}
+	db.Statement.WriteString("INTO ")
 	db.Statement.WriteQuoted(db.Statement.Table)
 	db.Statement.WriteString(" USING (")
 	for idx, value := range values.Values {
@@ -221,7 +882,9 @@ func MergeCreate(db *gorm.DB, onConflict clause.OnConflict, values clause.Values
 		db.Statement.WriteString(" FROM DUAL")
 	}
 
-	db.Statement.WriteString(") AS \"excluded\" (")
+	db.Statement.WriteString(") AS ")
+	db.Statement.WriteQuoted(excludedTable)
+	db.Statement.WriteString(" (")
 	for idx, column := range values.Columns {
 		if idx > 0 {
 			db.Statement.WriteByte(',')
@@ -231,21 +894,21 @@ func MergeCreate(db *gorm.DB, onConflict clause.OnConflict, values clause.Values
 	db.Statement.WriteString(") ON ")
 
 	var where clause.Where
-	for _, field := range db.Statement.Schema.PrimaryFields {
+	for _, column := range onConflict.Columns {
 		where.Exprs = append(where.Exprs, clause.Eq{
-			Column: clause.Column{Table: db.Statement.Table, Name: field.DBName},
-			Value:  clause.Column{Table: "excluded", Name: field.DBName},
+			Column: clause.Column{Table: db.Statement.Table, Name: column.Name},
+			Value:  clause.Column{Table: excludedTable, Name: column.Name},
 		})
 	}
 	where.Build(db.Statement)
 
-	if len(onConflict.DoUpdates) > 0 {
+	if len(onConflict.DoUpdates) > 0 && !onConflict.DoNothing {
 		// 将UPDATE子句中出现在关联条件中的列去除（即上面的ON子句），否则会报错：-4064:不能更新关联条件中的列
 		var withoutOnColumns = make([]clause.Assignment, 0, len(onConflict.DoUpdates))
 	a:
 		for _, assignment := range onConflict.DoUpdates {
-			for _, field := range db.Statement.Schema.PrimaryFields {
-				if assignment.Column.Name == field.DBName {
+			for _, column := range onConflict.Columns {
+				if assignment.Column.Name == column.Name {
 					continue a
 				}
 			}
@@ -255,50 +918,28 @@ func MergeCreate(db *gorm.DB, onConflict clause.OnConflict, values clause.Values
 		if len(onConflict.DoUpdates) > 0 {
 			db.Statement.WriteString(" WHEN MATCHED THEN UPDATE SET ")
 			onConflict.DoUpdates.Build(db.Statement)
-		}
-	}
-
-	db.Statement.WriteString(" WHEN NOT MATCHED THEN INSERT (")
-
-	written := false
-	for _, column := range values.Columns {
-		if db.Statement.Schema.PrioritizedPrimaryField == nil || !db.Statement.Schema.PrioritizedPrimaryField.AutoIncrement || db.Statement.Schema.PrioritizedPrimaryField.DBName != column.Name {
-			if written {
-				db.Statement.WriteByte(',')
+			if len(onConflict.Where.Exprs) > 0 {
+				db.Statement.WriteString(" WHERE ")
+				onConflict.Where.Build(db.Statement)
 			}
-			written = true
-			db.Statement.WriteQuoted(column.Name)
 		}
 	}
 
-	db.Statement.WriteString(") VALUES (")
-
-	written = false
-	for _, column := range values.Columns {
-		if db.Statement.Schema.PrioritizedPrimaryField == nil || !db.Statement.Schema.PrioritizedPrimaryField.AutoIncrement || db.Statement.Schema.PrioritizedPrimaryField.DBName != column.Name {
-			if written {
-				db.Statement.WriteByte(',')
-			}
-			written = true
-			db.Statement.WriteQuoted(clause.Column{
-				Table: "excluded",
-				Name:  column.Name,
-			})
+	columns, _ := insertColumns(db.Statement, values.Columns)
+	db.Statement.WriteString(" WHEN NOT MATCHED THEN INSERT ")
+	writeInsertColumns(db.Statement, columns)
+	db.Statement.WriteString(" VALUES (")
+	for idx, column := range columns {
+		if idx > 0 {
+			db.Statement.WriteByte(',')
 		}
+		db.Statement.WriteQuoted(clause.Column{
+			Table: excludedTable,
+			Name:  column.Name,
+		})
 	}
 
 	db.Statement.WriteString(")")
 	//outputInserted(db)
 	db.Statement.WriteString(";")
-
-	// merge into 语句插入的记录，无法通过LastInsertID获取
-	if db.Statement.Schema.PrioritizedPrimaryField != nil && db.Statement.Schema.PrioritizedPrimaryField.AutoIncrement {
-		db.Statement.WriteString("SELECT ")
-		db.Statement.WriteQuoted(db.Statement.Schema.PrioritizedPrimaryField.DBName)
-		db.Statement.WriteString(" FROM ")
-		db.Statement.WriteQuoted(db.Statement.Table)
-		db.Statement.WriteString(" ORDER BY ")
-		db.Statement.WriteQuoted(db.Statement.Schema.PrioritizedPrimaryField.DBName)
-		db.Statement.WriteString(" DESC LIMIT 1;")
-	}
 }
@@ -3,14 +3,17 @@ package dm
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
 	"strings"
 
 	//"dm"         // 引入dm数据库驱动包
 	"gorm.io/gorm" // 引入gorm v2包
 	"gorm.io/gorm/callbacks"
 	"gorm.io/gorm/clause"
-	"gorm.io/gorm/logger"
 	"gorm.io/gorm/migrator"
 	"gorm.io/gorm/schema"
 )
@@ -20,6 +23,18 @@ type Config struct {
 	DSN               string
 	Conn              gorm.ConnPool
 	DefaultStringSize uint
+	// Schema 默认模式。通过DSN打开连接时作为schema参数，Migrator对未指定模式的表在该模式下查找；
+	// 设置了Conn时不生效，连接的模式由调用方设置
+	Schema string
+	// ArrayBindInsert 多行插入时预编译单行INSERT，所有行作为一次数组绑定批量执行。
+	// 需要回填自增主键、序列生成的值或保存关联时仍使用普通插入，也可以通过 db.Set(ArrayBindInsertKey, true) 单独开启
+	ArrayBindInsert bool
+	// CompatibleOracle 按Oracle风格生成DDL（VARCHAR2、NUMBER(p,s)），查询条件中的 '' 按NULL处理。
+	// DSN中指定 compatibleMode=oracle 时自动开启，开启后通过DSN打开连接时也会加上该参数
+	CompatibleOracle bool
+	// UpperCaseIdentifiers 表名、列名等标识符统一转为大写，与不加引号创建的对象一致，用于已有的大写模式。
+	// 通过命名策略生成大写的表名和列名，column、index等标签中显式指定的名称也需要使用大写
+	UpperCaseIdentifiers bool
 }
 
 type Dialector struct {
@@ -54,10 +69,22 @@ func (d Dialector) Initialize(db *gorm.DB) (err error) {
 		d.DriverName = "dm"
 	}
 
+	if compatibleOracleDSN(d.DSN) {
+		d.CompatibleOracle = true
+	}
+
+	if d.UpperCaseIdentifiers {
+		db.NamingStrategy = upperCaseNamer{db.NamingStrategy}
+	}
+
 	if d.Conn != nil {
 		db.ConnPool = d.Conn
 	} else {
-		db.ConnPool, err = sql.Open(d.DriverName, d.DSN)
+		dsn := dsnWithParam(d.DSN, SchemaKey, d.Schema)
+		if d.CompatibleOracle {
+			dsn = dsnWithParam(dsn, CompatibleModeKey, "oracle")
+		}
+		db.ConnPool, err = sql.Open(d.DriverName, dsn)
 		if err != nil {
 			return
 		}
@@ -73,9 +100,87 @@ func (d Dialector) Initialize(db *gorm.DB) (err error) {
 	callbacks.RegisterDefaultCallbacks(db, callbackConfig)
 	db.Callback().Create().Replace("gorm:create", Create)
 
+	db.ClauseBuilders[clause.Locking{}.Name()] = buildLocking
+	if d.CompatibleOracle {
+		db.ClauseBuilders[clause.Where{}.Name()] = buildOracleWhere
+	}
+
 	return
 }
 
+var regLockingWait = regexp.MustCompile(`^WAIT \d+$`)
+
+// buildLocking 生成 FOR UPDATE [OF 列] [NOWAIT | WAIT n | SKIP LOCKED] 和 FOR READ ONLY，
+// 不支持的组合直接返回错误，而不是交给服务器报语法错误
+func buildLocking(c clause.Clause, builder clause.Builder) {
+	locking, ok := c.Expression.(clause.Locking)
+	if !ok {
+		c.Builder = nil
+		c.Build(builder)
+		return
+	}
+
+	addError := func(err error) {
+		if stmt, ok := builder.(*gorm.Statement); ok {
+			_ = stmt.AddError(err)
+		}
+	}
+
+	strength := strings.ToUpper(strings.Join(strings.Fields(locking.Strength), " "))
+	options := strings.ToUpper(strings.Join(strings.Fields(locking.Options), " "))
+	switch strength {
+	case clause.LockingStrengthUpdate:
+		switch {
+		case options == "", options == clause.LockingOptionsNoWait, options == clause.LockingOptionsSkipLocked:
+		case regLockingWait.MatchString(options):
+		default:
+			addError(fmt.Errorf("unsupported locking option %q, expected NOWAIT, WAIT n or SKIP LOCKED", locking.Options))
+			return
+		}
+	case "READ ONLY":
+		if options != "" || locking.Table.Name != "" {
+			addError(errors.New("FOR READ ONLY does not accept OF or locking options"))
+			return
+		}
+	default:
+		addError(fmt.Errorf("unsupported locking strength %q, expected UPDATE or READ ONLY", locking.Strength))
+		return
+	}
+
+	builder.WriteString("FOR ")
+	builder.WriteString(strength)
+	// DM的 OF 后面是列，Table.Name 可以写成 表.列
+	if locking.Table.Name != "" {
+		builder.WriteString(" OF ")
+		builder.WriteQuoted(locking.Table)
+	}
+	if options != "" {
+		builder.WriteByte(' ')
+		builder.WriteString(options)
+	}
+}
+
+// dsnWithParam 在DSN中未指定该参数时加上参数值，如Config.Schema
+func dsnWithParam(dsn, key, value string) string {
+	if value == "" {
+		return dsn
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+	query := u.Query()
+	for name := range query {
+		if strings.EqualFold(name, key) {
+			return dsn
+		}
+	}
+	query.Set(key, value)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
 func (d Dialector) DefaultValueOf(*schema.Field) clause.Expression {
 	// return clause.Expr{SQL: "DEFAULT VALUES"}
 	// 和gorm v1不一样，gorm v1是 INSERT INTO XXXX DEFAULT VALUES;
@@ -101,6 +206,10 @@ func (d Dialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v any)
 }
 
 func (d Dialector) QuoteTo(writer clause.Writer, str string) {
+	if d.Config != nil && d.UpperCaseIdentifiers {
+		str = upperIdentifier(str)
+	}
+
 	var (
 		underQuoted, selfQuoted bool
 		continuousBacktick      int8
@@ -148,11 +257,16 @@ func (d Dialector) QuoteTo(writer clause.Writer, str string) {
 	_ = writer.WriteByte('"')
 }
 
-func (d Dialector) Explain(sql string, vars ...any) string {
-	return logger.ExplainSQL(sql, nil, `'`, vars...)
-}
-
 func (d Dialector) DataTypeOf(field *schema.Field) string {
+	if isJSON(field) {
+		return d.getSchemaJSONType(field)
+	}
+	if d.CompatibleOracle {
+		if sqlType, ok := d.oracleDataTypeOf(field); ok {
+			return sqlType
+		}
+	}
+
 	switch field.DataType {
 	case schema.Bool:
 		return "BIT"
@@ -164,6 +278,8 @@ func (d Dialector) DataTypeOf(field *schema.Field) string {
 		return d.getSchemaStringType(field)
 	case schema.Time:
 		return d.getSchemaTimeType(field)
+	case "date":
+		return "DATE"
 	case schema.Bytes:
 		return d.getSchemaBytesType(field)
 	default:
@@ -189,7 +305,7 @@ func (d Dialector) getSchemaIntAndUnitType(field *schema.Field) string {
 		//if field.NotNull {
 		//	sqlType += " NOT NULL"
 		//}
-		if field.AutoIncrement {
+		if isIdentity(field) {
 			sqlType += " IDENTITY(1,1)"
 		}
 		return sqlType
@@ -239,8 +355,30 @@ func (d Dialector) getSchemaStringType(field *schema.Field) string {
 	}
 }
 
-func (d Dialector) getSchemaTimeType(_ *schema.Field) string {
-	sqlType := "TIMESTAMP WITH TIME ZONE"
+// getSchemaTimeType 小数秒精度取自`gorm:"precision:n"`，时区取自`gorm:"timezone:none|local"`，默认带时区
+func (d Dialector) getSchemaTimeType(field *schema.Field) string {
+	var precision string
+	if field.Precision > 0 {
+		precision = fmt.Sprintf("(%d)", field.Precision)
+	}
+
+	// 基于整数的时间类型（如datatypes.Time）只保存一天内的时间
+	fieldType := field.FieldType
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Struct {
+		return "TIME" + precision
+	}
+
+	sqlType := "TIMESTAMP" + precision
+	switch strings.ToUpper(field.TagSettings["TIMEZONE"]) {
+	case "NONE", "FALSE":
+	case "LOCAL":
+		sqlType += " WITH LOCAL TIME ZONE"
+	default:
+		sqlType += " WITH TIME ZONE"
+	}
 	//if field.NotNull || field.PrimaryKey {
 	//	sqlType += " NOT NULL"
 	//}
@@ -255,16 +393,41 @@ func (d Dialector) getSchemaBytesType(field *schema.Field) string {
 	return "BLOB"
 }
 
+// getSchemaJSONType JSON存储在CLOB（指定size时为VARCHAR）中，通过 IS JSON 检查约束保证内容合法
+func (d Dialector) getSchemaJSONType(field *schema.Field) string {
+	sqlType := "CLOB"
+	if field.Size > 0 && field.Size < 32768 {
+		sqlType = fmt.Sprintf("VARCHAR(%d)", field.Size)
+		if d.CompatibleOracle {
+			sqlType = "VARCHAR2" + strings.TrimPrefix(sqlType, "VARCHAR")
+		}
+	}
+
+	var column strings.Builder
+	d.QuoteTo(&column, field.DBName)
+	return sqlType + " CHECK (" + column.String() + " IS JSON)"
+}
+
 func (d Dialector) getSchemaCustomType(field *schema.Field) string {
 	sqlType := string(field.DataType)
 
-	if field.AutoIncrement && !strings.Contains(strings.ToLower(sqlType), " auto_increment") && !strings.Contains(strings.ToLower(sqlType), " identity") {
+	if isIdentity(field) && !strings.Contains(strings.ToLower(sqlType), " auto_increment") && !strings.Contains(strings.ToLower(sqlType), " identity") {
 		sqlType += " IDENTITY(1,1)"
 	}
 
 	return sqlType
 }
 
+// sequenceOf 返回字段通过`gorm:"sequence:SEQ_NAME"`指定的序列名
+func sequenceOf(field *schema.Field) string {
+	return field.TagSettings["SEQUENCE"]
+}
+
+// isIdentity 字段是否为IDENTITY自增列，使用序列生成值的字段不是
+func isIdentity(field *schema.Field) bool {
+	return field.AutoIncrement && sequenceOf(field) == ""
+}
+
 func (d Dialector) SavePoint(tx *gorm.DB, name string) error {
 	return tx.Exec("SAVEPOINT " + name).Error
 }
@@ -2,9 +2,33 @@ package dm
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/migrator"
 )
 
+// dryRunDB 返回只生成SQL、不连接数据库的gorm.DB
+func dryRunDB(t *testing.T, config Config) *gorm.DB {
+	config.DSN = "dm://SYSDBA:SYSDBA@127.0.0.1:5236"
+	db, err := gorm.Open(New(config), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open dry run db fail: %v", err)
+	}
+	return db
+}
+
 func TestDialector_QuoteTo(t *testing.T) {
 	testdatas := []struct {
 		raw    string
@@ -58,3 +82,971 @@ func BenchmarkDialector_QuoteTo(b *testing.B) {
 		buf.Reset()
 	}
 }
+
+type testUser struct {
+	ID   uint `gorm:"primaryKey;autoIncrement"`
+	Name string
+}
+
+func TestCreate_Returning(t *testing.T) {
+	db := dryRunDB(t, Config{})
+
+	testdatas := []struct {
+		name   string
+		run    func() *gorm.DB
+		expect string
+	}{
+		{"struct", func() *gorm.DB {
+			return db.Create(&testUser{Name: "a"})
+		}, `INSERT INTO "test_users" ("name") VALUES (?) RETURNING "id" INTO ?;`},
+		{"slice", func() *gorm.DB {
+			return db.Create(&[]testUser{{Name: "a"}, {Name: "b"}})
+		}, `BEGIN INSERT INTO "test_users" ("name") VALUES (?) RETURNING "id" INTO ?; INSERT INTO "test_users" ("name") VALUES (?) RETURNING "id" INTO ?; END;`},
+		{"merge", func() *gorm.DB {
+			return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&[]testUser{{ID: 1, Name: "a"}, {Name: "b"}})
+		}, `BEGIN MERGE INTO "test_users" USING (SELECT ?,? FROM DUAL) AS "excluded" ("name","id") ON "test_users"."id" = "excluded"."id" WHEN MATCHED THEN UPDATE SET "name"="excluded"."name" WHEN NOT MATCHED THEN INSERT ("name") VALUES ("excluded"."name"); ? := SQL%ROWCOUNT; INSERT INTO "test_users" ("name") VALUES (?) RETURNING "id" INTO ?; END;`},
+	}
+
+	for _, item := range testdatas {
+		if sql := item.run().Statement.SQL.String(); sql != item.expect {
+			t.Fatalf("%s: got %q, expect %q", item.name, sql, item.expect)
+		}
+	}
+}
+
+type testAccount struct {
+	ID    uint   `gorm:"primaryKey;autoIncrement"`
+	Email string `gorm:"uniqueIndex:idx_email;size:100"`
+	Name  string
+}
+
+func TestMergeCreate_OnConstraint(t *testing.T) {
+	db := dryRunDB(t, Config{})
+
+	sql := db.Clauses(clause.OnConflict{OnConstraint: "idx_email", UpdateAll: true}).Create(&testAccount{Email: "a", Name: "b"}).Statement.SQL.String()
+	expect := `BEGIN MERGE INTO "test_accounts" USING (SELECT ?,? FROM DUAL) AS "excluded" ("email","name") ON "test_accounts"."email" = "excluded"."email" WHEN MATCHED THEN UPDATE SET "name"="excluded"."name" WHEN NOT MATCHED THEN INSERT ("email","name") VALUES ("excluded"."email","excluded"."name"); ? := SQL%ROWCOUNT; SELECT "id" INTO ? FROM "test_accounts" WHERE "test_accounts"."email" = ?; END;`
+	if sql != expect {
+		t.Fatalf("got %q, expect %q", sql, expect)
+	}
+
+	if err := db.Clauses(clause.OnConflict{OnConstraint: "idx_unknown", DoNothing: true}).Create(&testAccount{Email: "a"}).Error; err == nil {
+		t.Fatalf("expect error for unknown constraint")
+	}
+
+	// 冲突列为NULL的行MERGE总是插入，直接 INSERT ... RETURNING INTO，不再按冲突列查询
+	type testContact struct {
+		ID    uint    `gorm:"primaryKey;autoIncrement"`
+		Email *string `gorm:"uniqueIndex:idx_contact_email"`
+	}
+	email := "a"
+	sql = db.Clauses(clause.OnConflict{OnConstraint: "idx_contact_email", DoNothing: true}).Create(&[]testContact{{Email: &email}, {}}).Statement.SQL.String()
+	expect = `BEGIN MERGE INTO "test_contacts" USING (SELECT ? FROM DUAL) AS "excluded" ("email") ON "test_contacts"."email" = "excluded"."email" WHEN NOT MATCHED THEN INSERT ("email") VALUES ("excluded"."email"); ? := SQL%ROWCOUNT; INSERT INTO "test_contacts" ("email") VALUES (?) RETURNING "id" INTO ?; SELECT "id" INTO ? FROM "test_contacts" WHERE "test_contacts"."email" = ?; END;`
+	if sql != expect {
+		t.Errorf("got %q, expect %q", sql, expect)
+	}
+
+	// 影响行数为块内MERGE的 SQL%ROWCOUNT（这里输出参数未赋值，为0）加上单独插入的行数
+	pool := &recordConnPool{}
+	recordDB, err := gorm.Open(New(Config{Conn: pool}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+	result := recordDB.Clauses(clause.OnConflict{DoNothing: true}).Create(&[]testUser{{ID: 1, Name: "a"}, {Name: "b"}})
+	if result.Error != nil || result.RowsAffected != 1 {
+		t.Errorf("expected 1 row affected, got %d (%v)", result.RowsAffected, result.Error)
+	}
+}
+
+type testOrder struct {
+	ID   int64 `gorm:"primaryKey;sequence:SEQ_ORDER_ID"`
+	Name string
+}
+
+func TestCreate_Sequence(t *testing.T) {
+	db := dryRunDB(t, Config{})
+
+	sql := db.Create(&testOrder{Name: "a"}).Statement.SQL.String()
+	expect := `INSERT INTO "test_orders" ("name","id") VALUES (?,"SEQ_ORDER_ID".NEXTVAL) RETURNING "id" INTO ?;`
+	if sql != expect {
+		t.Fatalf("got %q, expect %q", sql, expect)
+	}
+
+	// map创建时没有指定序列列也使用 NEXTVAL
+	sql = db.Model(&testOrder{}).Create(&[]map[string]any{{"Name": "a"}, {"Name": "b", "ID": int64(10)}}).Statement.SQL.String()
+	expect = `INSERT INTO "test_orders" ("id","name") VALUES ("SEQ_ORDER_ID".NEXTVAL,?),(?,?);`
+	if sql != expect {
+		t.Fatalf("got %q, expect %q", sql, expect)
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&testOrder{}); err != nil {
+		t.Fatalf("parse fail: %v", err)
+	}
+	if dataType := db.Dialector.DataTypeOf(stmt.Schema.PrioritizedPrimaryField); dataType != "BIGINT" {
+		t.Fatalf("sequence field should not be IDENTITY, got %q", dataType)
+	}
+}
+
+func TestSplitTableComment(t *testing.T) {
+	testdatas := []struct {
+		raw     string
+		options string
+		comment string
+		ok      bool
+	}{
+		{" STORAGE(ON MAIN)", " STORAGE(ON MAIN)", "", false},
+		{" COMMENT 'orders'", "", "orders", true},
+		{" STORAGE(ON MAIN) COMMENT='it''s'", " STORAGE(ON MAIN)", "it's", true},
+	}
+
+	for _, item := range testdatas {
+		options, comment, ok := splitTableComment(item.raw)
+		if options != item.options || comment != item.comment || ok != item.ok {
+			t.Fatalf("split %q fail, got (%q, %q, %v)", item.raw, options, comment, ok)
+		}
+	}
+
+	// 只有注释时建表语句中不能再带上原来的表选项
+	pool := &recordConnPool{}
+	db, err := gorm.Open(New(Config{Conn: pool}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+	if err := db.Set("gorm:table_options", " COMMENT 'plain table'").Migrator().CreateTable(&testUser{}); err != nil {
+		t.Fatalf("create table fail: %v", err)
+	}
+	if len(pool.execs) != 2 || strings.Contains(pool.execs[0], "COMMENT") || pool.execs[1] != `COMMENT ON TABLE "test_users" IS 'plain table'` {
+		t.Errorf("unexpected statements: %q", pool.execs)
+	}
+}
+
+type testQualified struct {
+	ID   int64
+	Name string `gorm:"index:idx_qualified_name"`
+}
+
+func (testQualified) TableName() string { return "OTHER.TEST_QUALIFIED" }
+
+func TestMigrator_TableSchema(t *testing.T) {
+	db := dryRunDB(t, Config{Schema: "APP"})
+	m := db.Migrator().(Migrator)
+
+	for _, tt := range []struct {
+		db     *gorm.DB
+		schema string
+		table  string
+	}{
+		{db.Model(&testQualified{}), "OTHER", "TEST_QUALIFIED"},
+		{db.Table("SCH.T"), "SCH", "T"},
+		{db.Table("T"), "APP", "T"},
+	} {
+		stmt := tt.db.Statement
+		if stmt.Model != nil {
+			if err := stmt.Parse(stmt.Model); err != nil {
+				t.Fatalf("parse fail: %v", err)
+			}
+		}
+		if schemaName, tableName := m.tableSchema(stmt); schemaName != tt.schema || tableName != tt.table {
+			t.Errorf("expected %s.%s, got %s.%s", tt.schema, tt.table, schemaName, tableName)
+		}
+	}
+	// 使用Config.Conn时Config.Schema不会应用到连接上，按会话实际的模式查找
+	conn := sql.OpenDB(catalogDriver{results: map[string][][]driver.Value{"CURRENT_SCHEMA": {{"SESSION_SCHEMA"}}}})
+	connDB, err := gorm.Open(New(Config{Conn: conn, Schema: "APP"}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+	if schemaName, _ := connDB.Migrator().(Migrator).tableSchema(connDB.Table("T").Statement); schemaName != "SESSION_SCHEMA" {
+		t.Errorf("expected session schema, got %s", schemaName)
+	}
+}
+
+func TestUpperCaseIdentifiers(t *testing.T) {
+	db := dryRunDB(t, Config{Schema: "app", UpperCaseIdentifiers: true})
+
+	sql := db.Where(map[string]any{"name": "a"}).Order("id").Find(&[]testUser{}).Statement.SQL.String()
+	if expected := `SELECT * FROM "TEST_USERS" WHERE "TEST_USERS"."NAME" = ? ORDER BY id`; sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+
+	// MERGE的别名与引用经过同一个QuoteTo，冲突列按小写列名给出
+	sql = db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, UpdateAll: true}).Create(&testUser{ID: 1, Name: "a"}).Statement.SQL.String()
+	if expected := `MERGE INTO "TEST_USERS" USING (SELECT ?,? FROM DUAL) AS "EXCLUDED" ("NAME","ID") ON "TEST_USERS"."ID" = "EXCLUDED"."ID" WHEN MATCHED THEN UPDATE SET "NAME"="EXCLUDED"."NAME" WHEN NOT MATCHED THEN INSERT ("NAME") VALUES ("EXCLUDED"."NAME");`; sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+	if err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "unknown"}}, DoNothing: true}).Create(&testUser{ID: 1}).Error; err == nil {
+		t.Errorf("expected error for unknown conflict column")
+	}
+
+	m := db.Migrator().(Migrator)
+	if schemaName, tableName := m.tableSchema(db.Table("orders").Statement); schemaName != "APP" || tableName != "ORDERS" {
+		t.Errorf("expected APP.ORDERS, got %s.%s", schemaName, tableName)
+	}
+}
+
+func TestTablePartition_Build(t *testing.T) {
+	db := dryRunDB(t, Config{})
+	stmt := &gorm.Statement{DB: db}
+
+	for _, tt := range []struct {
+		partition TablePartition
+		expected  string
+	}{
+		{TablePartition{Type: PartitionHash, Columns: []string{"id"}, Count: 4}, `PARTITION BY HASH("id") PARTITIONS 4`},
+		{
+			TablePartition{Type: PartitionList, Columns: []string{"region"}, Partitions: []Partition{{Name: "p_north", Values: "'BJ', 'TJ'"}, {Name: "p_other", Values: "DEFAULT"}}},
+			`PARTITION BY LIST("region") (PARTITION "p_north" VALUES ('BJ', 'TJ'), PARTITION "p_other" VALUES (DEFAULT))`,
+		},
+	} {
+		if sql := tt.partition.build(stmt); sql != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, sql)
+		}
+	}
+}
+
+// offlineDriver 所有语句都返回错误，用于不连接数据库生成迁移计划
+type offlineDriver struct{}
+
+func (offlineDriver) Open(string) (driver.Conn, error) { return offlineConn{}, nil }
+
+func (offlineDriver) Connect(context.Context) (driver.Conn, error) { return offlineConn{}, nil }
+
+func (d offlineDriver) Driver() driver.Driver { return d }
+
+type offlineConn struct{}
+
+func (offlineConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("offline") }
+func (offlineConn) Close() error                        { return nil }
+func (offlineConn) Begin() (driver.Tx, error)           { return nil, errors.New("offline") }
+
+func TestMigrator_PlanMigrate(t *testing.T) {
+	sql.Register("dm-offline", offlineDriver{})
+	conn, _ := sql.Open("dm-offline", "")
+	db, err := gorm.Open(New(Config{Conn: conn}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+
+	// 查询表是否存在失败时按表不存在处理，计划中应包含序列、建表和索引
+	plan, err := db.Migrator().(Migrator).PlanMigrate(&testOrder{})
+	if err != nil {
+		t.Fatalf("plan fail: %v", err)
+	}
+	if len(plan) < 2 || !strings.HasPrefix(plan[0].SQL, "CREATE SEQUENCE") || !strings.HasPrefix(plan[1].SQL, "CREATE TABLE") {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	if plan[1].Reason != "table test_orders does not exist" {
+		t.Errorf("unexpected reason: %s", plan[1].Reason)
+	}
+}
+
+// catalogDriver 查询按语句中包含的关键字返回预设的结果，没有匹配时返回空结果，执行的语句都成功
+type catalogDriver struct {
+	results map[string][][]driver.Value
+}
+
+func (d catalogDriver) Open(string) (driver.Conn, error) { return catalogConn(d), nil }
+
+func (d catalogDriver) Connect(context.Context) (driver.Conn, error) { return catalogConn(d), nil }
+
+func (d catalogDriver) Driver() driver.Driver { return d }
+
+type catalogConn catalogDriver
+
+func (catalogConn) Prepare(string) (driver.Stmt, error)      { return nil, errors.New("offline") }
+func (catalogConn) Close() error                             { return nil }
+func (catalogConn) Begin() (driver.Tx, error)                { return nil, errors.New("offline") }
+func (catalogConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (catalogConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (c catalogConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	for key, rows := range c.results {
+		if strings.Contains(query, key) {
+			return &catalogRows{rows: rows}, nil
+		}
+	}
+	return &catalogRows{}, nil
+}
+
+type catalogRows struct {
+	rows [][]driver.Value
+}
+
+func (r *catalogRows) Columns() []string {
+	columns := []string{"C1"}
+	if len(r.rows) > 0 {
+		columns = make([]string, len(r.rows[0]))
+		for i := range columns {
+			columns[i] = fmt.Sprintf("C%d", i+1)
+		}
+	}
+	return columns
+}
+
+func (r *catalogRows) Close() error { return nil }
+
+func (r *catalogRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestMigrator_ConvertColumn(t *testing.T) {
+	// 原列上有CHECK约束和唯一索引，删除原列后需要重新创建
+	conn := sql.OpenDB(catalogDriver{results: map[string][][]driver.Value{
+		"CONS.FACTION":      {{"CHK_EMAIL", "C", int64(0), int64(0), `"email" LIKE '%@%'`, nil}},
+		"SYSCONTEXTINDEXES": {{"idx_email", "UNIQUE", "email", int64(1)}},
+	}})
+	db, err := gorm.Open(New(Config{Conn: conn}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+	var plan MigrationPlan
+	tx := db.Session(&gorm.Session{})
+	tx.Statement.ConnPool = &planConnPool{ConnPool: db.ConnPool, plan: &plan}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&testAccount{}); err != nil {
+		t.Fatalf("parse fail: %v", err)
+	}
+	// CLOB 不能直接 MODIFY 为 VARCHAR
+	current := migrator.ColumnType{
+		NameValue:          sql.NullString{String: "email", Valid: true},
+		DataTypeValue:      sql.NullString{String: "CLOB", Valid: true},
+		LengthValue:        sql.NullInt64{Int64: 2147483647, Valid: true},
+		DecimalSizeValue:   sql.NullInt64{Valid: true},
+		NullableValue:      sql.NullBool{Bool: true, Valid: true},
+		UniqueValue:        sql.NullBool{Valid: true},
+		AutoIncrementValue: sql.NullBool{Valid: true},
+		CommentValue:       sql.NullString{Valid: true},
+	}
+	if err := tx.Migrator().MigrateColumn(&testAccount{}, stmt.Schema.LookUpField("Email"), current); err != nil {
+		t.Fatalf("migrate column fail: %v", err)
+	}
+
+	expected := []string{
+		`ALTER TABLE "test_accounts" ADD "email$TMP" VARCHAR(100)`,
+		`UPDATE "test_accounts" SET "email$TMP" = CAST("email" AS VARCHAR(100))`,
+		`ALTER TABLE "test_accounts" DROP COLUMN "email" CASCADE`,
+		`ALTER TABLE "test_accounts" RENAME COLUMN "email$TMP" TO "email"`,
+		`ALTER TABLE "test_accounts" MODIFY "email" VARCHAR(100)`,
+		`ALTER TABLE "test_accounts" ADD CONSTRAINT "CHK_EMAIL" CHECK ("email" LIKE '%@%')`,
+		`CREATE UNIQUE INDEX "idx_email" ON "test_accounts"("email")`,
+	}
+	var executed []string
+	for _, step := range plan {
+		executed = append(executed, step.SQL)
+	}
+	if !reflect.DeepEqual(executed, expected) {
+		t.Errorf("expected %q, got %q", expected, executed)
+	}
+}
+
+type testStaging struct {
+	ID      int64
+	Payload string
+}
+
+func (testStaging) TemporaryTable() OnCommit { return OnCommitPreserveRows }
+
+func TestMigrator_TemporaryTable(t *testing.T) {
+	pool := &recordConnPool{}
+	db, err := gorm.Open(New(Config{Conn: pool}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+
+	if err := db.Migrator().CreateTable(&testStaging{}); err != nil {
+		t.Fatalf("create table fail: %v", err)
+	}
+	if len(pool.execs) != 1 || !strings.HasPrefix(pool.execs[0], `CREATE GLOBAL TEMPORARY TABLE "test_stagings" (`) ||
+		!strings.HasSuffix(pool.execs[0], ") ON COMMIT PRESERVE ROWS") {
+		t.Errorf("unexpected statements: %q", pool.execs)
+	}
+}
+
+func TestMigrator_MaterializedView(t *testing.T) {
+	pool := &recordConnPool{}
+	db, err := gorm.Open(New(Config{Conn: pool}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+
+	m := db.Migrator().(Migrator)
+	query := db.Model(&testUser{}).Select("name, COUNT(*) AS total").Group("name")
+	if err := m.CreateMaterializedView("APP.user_names", MaterializedViewOption{Query: query, Method: RefreshComplete, Mode: RefreshOnDemand}); err != nil {
+		t.Fatalf("create materialized view fail: %v", err)
+	}
+	if err := m.RefreshMaterializedView("APP.user_names", RefreshFast); err != nil {
+		t.Fatalf("refresh materialized view fail: %v", err)
+	}
+
+	expected := []string{
+		`CREATE MATERIALIZED VIEW "APP"."user_names" REFRESH COMPLETE ON DEMAND AS SELECT name, COUNT(*) AS total FROM "test_users" GROUP BY "name"`,
+		`REFRESH MATERIALIZED VIEW "APP"."user_names" FAST`,
+	}
+	if !reflect.DeepEqual(pool.execs, expected) {
+		t.Errorf("expected %q, got %q", expected, pool.execs)
+	}
+}
+
+func TestColumnType_Fill(t *testing.T) {
+	for _, tt := range []struct {
+		dataType      string
+		length, scale int64
+		expected      string
+		hasLength     bool
+		hasDecimal    bool
+	}{
+		{"VARCHAR", 100, 0, "VARCHAR(100)", true, false},
+		{"DEC", 10, 2, "DEC(10,2)", false, true},
+		{"BIGINT", 8, 0, "BIGINT", false, false},
+		{"TIMESTAMP", 8, 3 | localTimeZoneScaleMask, "TIMESTAMP(3) WITH LOCAL TIME ZONE", false, true},
+	} {
+		var column ColumnType
+		column.fill(tt.dataType, tt.length, tt.scale)
+		columnType, _ := column.ColumnType()
+		_, hasLength := column.Length()
+		_, _, hasDecimal := column.DecimalSize()
+		if columnType != tt.expected || hasLength != tt.hasLength || hasDecimal != tt.hasDecimal {
+			t.Errorf("%s: expected %s (length %t, decimal %t), got %s (length %t, decimal %t)",
+				tt.dataType, tt.expected, tt.hasLength, tt.hasDecimal, columnType, hasLength, hasDecimal)
+		}
+	}
+}
+
+type testCustomer struct {
+	ID int64
+}
+
+type testInvoice struct {
+	ID         int64
+	CustomerID int64
+	Customer   testCustomer `gorm:"constraint:OnDelete:CASCADE"`
+	Amount     int64        `gorm:"check:chk_invoice_amount,amount > 0"`
+}
+
+func TestConstraintDrift(t *testing.T) {
+	db := dryRunDB(t, Config{})
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&testInvoice{}); err != nil {
+		t.Fatalf("parse fail: %v", err)
+	}
+	expected := stmt.Schema.Relationships.Relations["Customer"].ParseConstraint()
+
+	onUpdate, onDelete := foreignKeyActions("NN")
+	current := Constraint{
+		Name: expected.Name, Type: ConstraintForeignKey, Columns: []string{"CUSTOMER_ID"},
+		RefTable: "test_customers", RefColumns: []string{"id"}, OnUpdate: onUpdate, OnDelete: onDelete,
+	}
+	if reason := foreignKeyDrift(current, expected); reason != "on delete NO ACTION -> CASCADE" {
+		t.Errorf("unexpected drift: %q", reason)
+	}
+	current.OnDelete = "CASCADE"
+	if reason := foreignKeyDrift(current, expected); reason != "" {
+		t.Errorf("unexpected drift: %q", reason)
+	}
+	if sameNames([]string{"ORDER_ID", "LINE_NO"}, []string{"line_no", "order_id"}) {
+		t.Errorf("composite key columns in a different order should drift")
+	}
+
+	if check := stmt.Schema.ParseCheckConstraints()["chk_invoice_amount"]; normalizeCheck(check.Constraint) != normalizeCheck(`("AMOUNT" > 0)`) {
+		t.Errorf("check constraint %q should match catalog text", check.Constraint)
+	}
+}
+
+type testDocument struct {
+	ID    int64
+	Attrs json.RawMessage
+	Tags  []byte `gorm:"type:json;size:4000"`
+}
+
+func TestJSON(t *testing.T) {
+	db := dryRunDB(t, Config{})
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&testDocument{}); err != nil {
+		t.Fatalf("parse fail: %v", err)
+	}
+	if dataType := db.Dialector.DataTypeOf(stmt.Schema.LookUpField("Attrs")); dataType != `CLOB CHECK ("attrs" IS JSON)` {
+		t.Errorf("unexpected data type: %s", dataType)
+	}
+	if dataType := db.Dialector.DataTypeOf(stmt.Schema.LookUpField("Tags")); dataType != `VARCHAR(4000) CHECK ("tags" IS JSON)` {
+		t.Errorf("unexpected data type: %s", dataType)
+	}
+
+	// MODIFY时不再添加IS JSON检查
+	pool := &recordConnPool{}
+	recordDB, err := gorm.Open(New(Config{Conn: pool}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+	if err := recordDB.Migrator().AlterColumn(&testDocument{}, "Tags"); err != nil {
+		t.Fatalf("alter column fail: %v", err)
+	}
+	if len(pool.execs) != 1 || pool.execs[0] != `ALTER TABLE "test_documents" MODIFY "tags" VARCHAR(4000)` {
+		t.Errorf("unexpected statements: %q", pool.execs)
+	}
+
+	result := db.Model(&testDocument{}).
+		Where(JSONValue("attrs", "$.size").Returning("NUMBER").Gt(10)).
+		Where(JSONExists("attrs", "$.tags")).
+		Where(JSONQuery("attrs", "$.o'k").WithWrapper().Neq(nil)).
+		Find(&[]testDocument{})
+	expected := `SELECT * FROM "test_documents" WHERE JSON_VALUE("attrs", '$.size' RETURNING NUMBER) > ? AND JSON_EXISTS("attrs", '$.tags') AND JSON_QUERY("attrs", '$.o''k' WITH WRAPPER) IS NOT NULL`
+	if sql := result.Statement.SQL.String(); sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+}
+
+type testEvent struct {
+	ID        int64
+	CreatedAt time.Time
+	StartedAt time.Time  `gorm:"precision:3"`
+	LoggedAt  *time.Time `gorm:"precision:6;timezone:local"`
+	PlainAt   time.Time  `gorm:"timezone:none"`
+	Day       string     `gorm:"type:date"`
+	Clock     testClock  `gorm:"precision:2"`
+}
+
+// testClock 与datatypes.Time一样，用整数保存一天内的时间
+type testClock time.Duration
+
+func (testClock) GormDataType() string { return "time" }
+
+func TestDataTypeOf_Time(t *testing.T) {
+	db := dryRunDB(t, Config{})
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&testEvent{}); err != nil {
+		t.Fatalf("parse fail: %v", err)
+	}
+
+	for name, expected := range map[string]string{
+		"CreatedAt": "TIMESTAMP WITH TIME ZONE",
+		"StartedAt": "TIMESTAMP(3) WITH TIME ZONE",
+		"LoggedAt":  "TIMESTAMP(6) WITH LOCAL TIME ZONE",
+		"PlainAt":   "TIMESTAMP",
+		"Day":       "DATE",
+		"Clock":     "TIME(2)",
+	} {
+		if dataType := db.Dialector.DataTypeOf(stmt.Schema.LookUpField(name)); dataType != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, dataType)
+		}
+	}
+
+	// 系统表中的类型不含精度，精度一致时不修改列，精度不同时修改
+	pool := &recordConnPool{}
+	recordDB, err := gorm.Open(New(Config{Conn: pool}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+	for _, scale := range []int64{3, 6} {
+		var current ColumnType
+		current.fill("TIMESTAMP WITH TIME ZONE", 10, scale)
+		current.column.NameValue = sql.NullString{String: "started_at", Valid: true}
+		current.column.NullableValue = sql.NullBool{Bool: true, Valid: true}
+		if err := recordDB.Migrator().MigrateColumn(&testEvent{}, stmt.Schema.LookUpField("StartedAt"), current); err != nil {
+			t.Fatalf("migrate column fail: %v", err)
+		}
+	}
+	if len(pool.execs) != 1 || pool.execs[0] != `ALTER TABLE "test_events" MODIFY "started_at" TIMESTAMP(3) WITH TIME ZONE` {
+		t.Errorf("unexpected statements: %q", pool.execs)
+	}
+}
+
+func TestCompatibleOracle(t *testing.T) {
+	db := dryRunDB(t, Config{CompatibleOracle: true})
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&testAccount{}); err != nil {
+		t.Fatalf("parse fail: %v", err)
+	}
+
+	for name, expected := range map[string]string{
+		"ID":    "NUMBER(19) IDENTITY(1,1)",
+		"Email": "VARCHAR2(100)",
+	} {
+		if dataType := db.Dialector.DataTypeOf(stmt.Schema.LookUpField(name)); dataType != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, dataType)
+		}
+	}
+
+	sql := db.Where(map[string]any{"name": ""}).Not(map[string]any{"email": ""}).Find(&[]testAccount{}).Statement.SQL.String()
+	if expected := `SELECT * FROM "test_accounts" WHERE "test_accounts"."name" IS NULL AND "test_accounts"."email" IS NOT NULL`; sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+
+	if aliases := db.Migrator().GetTypeAliases("varchar2"); !reflect.DeepEqual(aliases[:1], []string{"varchar"}) {
+		t.Errorf("unexpected aliases for varchar2: %v", aliases)
+	}
+	if aliases := dryRunDB(t, Config{}).Migrator().GetTypeAliases("varchar2"); len(aliases) != 0 {
+		t.Errorf("expected no Oracle aliases without CompatibleOracle, got %v", aliases)
+	}
+	dialector := New(Config{DSN: "dm://SYSDBA:SYSDBA@127.0.0.1:5236?compatibleMode=oracle"})
+	if _, err := gorm.Open(dialector, &gorm.Config{DryRun: true, DisableAutomaticPing: true}); err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+	if !dialector.(*Dialector).CompatibleOracle {
+		t.Errorf("expected compatibleMode=oracle in DSN to enable CompatibleOracle")
+	}
+}
+
+func TestLocking(t *testing.T) {
+	db := dryRunDB(t, Config{})
+
+	for _, tt := range []struct {
+		locking  clause.Locking
+		expected string
+	}{
+		{clause.Locking{Strength: "UPDATE"}, "FOR UPDATE"},
+		{clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}, "FOR UPDATE NOWAIT"},
+		{clause.Locking{Strength: "UPDATE", Options: "wait  5"}, "FOR UPDATE WAIT 5"},
+		{clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "test_users.name"}, Options: "SKIP LOCKED"}, `FOR UPDATE OF "test_users"."name" SKIP LOCKED`},
+	} {
+		result := db.Clauses(tt.locking).Find(&[]testUser{})
+		if result.Error != nil {
+			t.Fatalf("unexpected error: %v", result.Error)
+		}
+		if sql := result.Statement.SQL.String(); !strings.HasSuffix(sql, " "+tt.expected) {
+			t.Errorf("expected suffix %s, got %s", tt.expected, sql)
+		}
+	}
+
+	for _, locking := range []clause.Locking{
+		{Strength: "SHARE"},
+		{Strength: "UPDATE", Options: "WAIT"},
+		{Strength: "UPDATE", Options: "NOWAIT SKIP LOCKED"},
+	} {
+		if err := db.Clauses(locking).Find(&[]testUser{}).Error; err == nil {
+			t.Errorf("expected error for %+v", locking)
+		}
+	}
+}
+
+func TestHints(t *testing.T) {
+	db := dryRunDB(t, Config{})
+	hint := Hint("INDEX(test_users idx_name) PARALLEL(4)", "ENABLE_HASH_JOIN(1)")
+	expected := "/*+ INDEX(test_users idx_name) PARALLEL(4) ENABLE_HASH_JOIN(1) */"
+
+	for _, sql := range []string{
+		db.Clauses(hint).Find(&[]testUser{}).Statement.SQL.String(),
+		db.Clauses(hint).Model(&testUser{}).Where("id = ?", 1).Update("name", "a").Statement.SQL.String(),
+		db.Clauses(hint).Where("id = ?", 1).Delete(&testUser{}).Statement.SQL.String(),
+		db.Clauses(hint, clause.OnConflict{UpdateAll: true}).Create(&testUser{ID: 1, Name: "a"}).Statement.SQL.String(),
+	} {
+		if keyword, _, _ := strings.Cut(sql, " "); !strings.HasPrefix(sql, keyword+" "+expected+" ") {
+			t.Errorf("hint not placed after %s: %s", keyword, sql)
+		}
+	}
+
+	if err := db.Clauses(Hint("INDEXX(t idx)")).Find(&[]testUser{}).Error; err == nil {
+		t.Errorf("expected error for unknown hint")
+	}
+}
+
+type testArticle struct {
+	ID      int64
+	Content string `gorm:"index:idx_article_content,class:CONTEXT,option:LEXER CHINESE_LEXER SYNC"`
+}
+
+func TestContextIndex(t *testing.T) {
+	pool := &recordConnPool{}
+	db, err := gorm.Open(New(Config{Conn: pool}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+
+	m := db.Migrator()
+	if err := m.CreateIndex(&testArticle{}, "idx_article_content"); err != nil {
+		t.Fatalf("create index fail: %v", err)
+	}
+	if err := m.DropIndex(&testArticle{}, "idx_article_content"); err != nil {
+		t.Fatalf("drop index fail: %v", err)
+	}
+	expected := []string{
+		`CREATE CONTEXT INDEX "idx_article_content" ON "test_articles"("content") LEXER CHINESE_LEXER SYNC`,
+		`DROP CONTEXT INDEX "idx_article_content" ON "test_articles"`,
+	}
+	if !reflect.DeepEqual(pool.execs, expected) {
+		t.Errorf("expected %q, got %q", expected, pool.execs)
+	}
+
+	sql := db.Session(&gorm.Session{DryRun: true}).Where(ContainsAny("content", "达梦", "DM8").Not("O'racle")).Find(&[]testArticle{}).Statement.SQL.String()
+	if expected := `SELECT * FROM "test_articles" WHERE CONTAINS("content", ('达梦' OR 'DM8') AND NOT 'O''racle')`; sql != expected {
+		t.Errorf("expected %s, got %s", expected, sql)
+	}
+	if err := db.Session(&gorm.Session{DryRun: true}).Where(Contains("content").Not("DM7")).Find(&[]testArticle{}).Error; err == nil {
+		t.Errorf("expected error for CONTAINS without search terms")
+	}
+}
+
+// recordConnPool 记录执行的语句，每条语句返回的影响行数为1
+type recordConnPool struct {
+	execs []string
+	args  [][]any
+}
+
+func (p *recordConnPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New("offline")
+}
+
+func (p *recordConnPool) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	p.execs = append(p.execs, query)
+	p.args = append(p.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (p *recordConnPool) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, errors.New("offline")
+}
+
+func (p *recordConnPool) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return sql.OpenDB(offlineDriver{}).QueryRowContext(ctx, query, args...)
+}
+
+// failConnPool 执行时返回指定的错误
+type failConnPool struct {
+	recordConnPool
+	err error
+}
+
+func (p *failConnPool) ExecContext(context.Context, string, ...any) (sql.Result, error) {
+	return nil, p.err
+}
+
+func TestCreate_Chunks(t *testing.T) {
+	defer func(params int) { maxBatchParams = params }(maxBatchParams)
+	// 每行一个列值和一个输出参数，每批最多两行
+	maxBatchParams = 5
+
+	pool := &recordConnPool{}
+	db, err := gorm.Open(New(Config{Conn: pool}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+
+	users := []testUser{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+	result := db.Create(&users)
+	if result.Error != nil {
+		t.Fatalf("create fail: %v", result.Error)
+	}
+	if len(pool.execs) != 3 {
+		t.Fatalf("expected 3 batches, got %d: %v", len(pool.execs), pool.execs)
+	}
+	if result.RowsAffected != 5 {
+		t.Errorf("expected 5 rows affected, got %d", result.RowsAffected)
+	}
+
+	chunks := chunkRows(clause.Values{Values: [][]any{{"aaaa"}, {"bbbb"}, {"cc"}, {"d"}}}, 1, 100, 6)
+	if expected := [][2]int{{0, 1}, {1, 3}, {3, 4}}; !reflect.DeepEqual(chunks, expected) {
+		t.Errorf("expected %v, got %v", expected, chunks)
+	}
+}
+
+func TestCreate_ArrayBind(t *testing.T) {
+	pool := &recordConnPool{}
+	db, err := gorm.Open(New(Config{Conn: pool, ArrayBindInsert: true}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+
+	name := "c"
+	type testTag struct {
+		Code  string `gorm:"primaryKey"`
+		Name  string
+		Alias *string
+	}
+	if err := db.Create(&[]testTag{{Code: "x", Name: "a"}, {Code: "y", Name: "b", Alias: &name}}).Error; err != nil {
+		t.Fatalf("create fail: %v", err)
+	}
+	if expected := `INSERT INTO "test_tags" ("code","name","alias") VALUES (?,?,?)`; len(pool.execs) != 1 || pool.execs[0] != expected {
+		t.Fatalf("expected %s, got %v", expected, pool.execs)
+	}
+	if rows, ok := pool.args[0][0].([][]any); !ok || !reflect.DeepEqual(rows, [][]any{{"x", "a", nil}, {"y", "b", "c"}}) {
+		t.Errorf("unexpected batch args: %#v", pool.args[0])
+	}
+
+	// continueBatchOnError 时按驱动返回的每行执行结果统计插入的行数
+	batchErr := *EC_BP_WITH_ERROR
+	batchErr.updateCounts = []int64{1, -1}
+	failDB, err := gorm.Open(New(Config{Conn: &failConnPool{err: &batchErr}, ArrayBindInsert: true}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+	result := failDB.Create(&[]testTag{{Code: "x", Name: "a"}, {Code: "x", Name: "b"}})
+	if errs, ok := result.Get(BatchErrorsKey); result.Error != nil || result.RowsAffected != 1 || !ok || len(errs.([]error)) != 1 {
+		t.Errorf("expected 1 row affected with batch errors, got %d (%v)", result.RowsAffected, result.Error)
+	}
+
+	// 自增主键需要回填，数组绑定取不回生成的值，回到普通的插入
+	pool.execs = nil
+	if err := db.Create(&[]testUser{{Name: "a"}, {Name: "b"}}).Error; err != nil {
+		t.Fatalf("create fail: %v", err)
+	}
+	if len(pool.execs) != 1 || !strings.HasPrefix(pool.execs[0], "BEGIN ") {
+		t.Errorf("expected anonymous block, got %v", pool.execs)
+	}
+
+	// 序列的NEXTVAL不能数组绑定，回到普通的插入
+	pool.execs = nil
+	if err := db.Create(&[]testOrder{{Name: "a"}, {Name: "b"}}).Error; err != nil {
+		t.Fatalf("create fail: %v", err)
+	}
+	if len(pool.execs) != 1 || !strings.HasPrefix(pool.execs[0], "BEGIN ") {
+		t.Errorf("expected anonymous block, got %v", pool.execs)
+	}
+}
+
+// sessionDriver 记录每条语句在哪个连接上执行，INSERT 语句返回错误
+type sessionDriver struct {
+	conns int
+	execs []string
+}
+
+func (d *sessionDriver) Open(string) (driver.Conn, error) {
+	d.conns++
+	return &sessionConn{driver: d, id: d.conns}, nil
+}
+
+type sessionConn struct {
+	driver *sessionDriver
+	id     int
+}
+
+func (c *sessionConn) Prepare(string) (driver.Stmt, error)      { return nil, errors.New("offline") }
+func (c *sessionConn) Close() error                             { return nil }
+func (c *sessionConn) Begin() (driver.Tx, error)                { return nil, errors.New("offline") }
+func (c *sessionConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *sessionConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.driver.execs = append(c.driver.execs, fmt.Sprintf("%d:%s", c.id, query))
+	if strings.HasPrefix(query, "INSERT") {
+		return nil, errors.New("insert fail")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func TestCreate_IdentityInsertSession(t *testing.T) {
+	d := &sessionDriver{}
+	sql.Register("dm-session", d)
+	conn, _ := sql.Open("dm-session", "")
+	db, err := gorm.Open(New(Config{Conn: conn}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+
+	// 占用第一个连接，开关和插入都应在第二个连接上执行
+	busy, _ := conn.Conn(context.Background())
+	defer busy.Close()
+
+	if err := db.Create(&testUser{ID: 5, Name: "a"}).Error; err == nil {
+		t.Fatalf("expected insert error")
+	}
+	if len(d.execs) != 3 || !strings.HasSuffix(d.execs[0], ` ON;`) || !strings.HasSuffix(d.execs[2], ` OFF;`) {
+		t.Fatalf("unexpected statements: %v", d.execs)
+	}
+	for _, exec := range d.execs {
+		if id, _, _ := strings.Cut(exec, ":"); id != "2" {
+			t.Errorf("statement not on the pinned connection: %v", d.execs)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	pool := &recordConnPool{}
+	db, err := gorm.Open(New(Config{Conn: pool}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open db fail: %v", err)
+	}
+
+	var (
+		total  int64
+		status = "NEW"
+		users  = []testUser{{ID: 1}}
+		name   string
+	)
+	if err := Call(db, "SHOP.LIST_USERS", In("P_ID", 1), Out("P_TOTAL", &total), InOut("P_STATUS", &status), Cursor("P_USERS", &users)); err != nil {
+		t.Fatalf("call fail: %v", err)
+	}
+	if err := CallFunction(db, "SHOP.USER_NAME", &name, In("P_ID", 1)); err != nil {
+		t.Fatalf("call function fail: %v", err)
+	}
+
+	expected := []string{
+		`BEGIN "SHOP"."LIST_USERS"("P_ID" => ?, "P_TOTAL" => ?, "P_STATUS" => ?, "P_USERS" => ?); END;`,
+		`BEGIN ? := "SHOP"."USER_NAME"("P_ID" => ?); END;`,
+	}
+	if !reflect.DeepEqual(pool.execs, expected) {
+		t.Errorf("expected %q, got %q", expected, pool.execs)
+	}
+	if out, ok := pool.args[0][2].(sql.Out); !ok || !out.In || out.Dest != &status {
+		t.Errorf("expected INOUT parameter, got %#v", pool.args[0][2])
+	}
+	// 没有打开的游标时结果为空
+	if len(users) != 0 {
+		t.Errorf("expected empty cursor result, got %v", users)
+	}
+}
+
+func TestDialector_Translate(t *testing.T) {
+	dialector := New(Config{}).(gorm.ErrorTranslator)
+
+	err := dialector.Translate(newDmError(-6602, "error.unique"))
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("expected ErrDuplicatedKey, got %v", err)
+	}
+	var dmErr *DmError
+	if !errors.As(err, &dmErr) || dmErr.ErrCode != -6602 {
+		t.Errorf("original DmError not reachable: %v", err)
+	}
+
+	if err := dialector.Translate(fmt.Errorf("wrapped: %w", newDmError(-6625, "error.fk"))); !errors.Is(err, gorm.ErrForeignKeyViolated) {
+		t.Errorf("expected ErrForeignKeyViolated, got %v", err)
+	}
+	if original := newDmError(-2106, "error.syntax"); dialector.Translate(original) != error(original) {
+		t.Errorf("unknown error code should not be translated")
+	}
+}
+
+func TestDialector_Explain(t *testing.T) {
+	dialector := New(Config{})
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 120000000, time.FixedZone("", 8*3600))
+	decimal, _ := NewDecimalFromString("12.50")
+	interval, _ := NewDmIntervalDTByString("INTERVAL '1 02:03:04' DAY TO SECOND")
+	name := "it's"
+
+	for _, tt := range []struct {
+		value    any
+		expected string
+	}{
+		{nil, "NULL"},
+		{(*string)(nil), "NULL"},
+		{&name, "'it''s'"},
+		{true, "1"},
+		{int64(-3), "-3"},
+		{1.5, "1.5"},
+		{time.May, "5"},
+		{ts, "TIMESTAMP '2024-05-06 07:08:09.12 +08:00'"},
+		{&ts, "TIMESTAMP '2024-05-06 07:08:09.12 +08:00'"},
+		{[]byte{0x0a, 0xff}, "0x0AFF"},
+		{json.RawMessage(`{"a":1}`), `'{"a":1}'`},
+		{sql.NullString{}, "NULL"},
+		{sql.NullInt64{Int64: 7, Valid: true}, "7"},
+		{decimal, decimal.String()},
+		{interval, interval.String()},
+		{NewBlob([]byte("ab")), "0x6162"},
+		{NewClob("a'b"), "'a''b'"},
+	} {
+		if sql := dialector.Explain("?", tt.value); sql != tt.expected {
+			t.Errorf("explain %#v: expected %s, got %s", tt.value, tt.expected, sql)
+		}
+	}
+
+	if sql := dialector.Explain(`SELECT '?', "a?" FROM t WHERE id = ? AND name = ?`, 1, "b"); sql != `SELECT '?', "a?" FROM t WHERE id = 1 AND name = 'b'` {
+		t.Errorf("unexpected sql: %s", sql)
+	}
+}
@@ -0,0 +1,28 @@
+package dm
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// errCodes DM服务器错误码与gorm错误的对应关系
+var errCodes = map[int32]error{
+	-6602: gorm.ErrDuplicatedKey,           // 违反唯一性约束
+	-6604: gorm.ErrCheckConstraintViolated, // 违反CHECK约束
+	-6625: gorm.ErrForeignKeyViolated,      // 违反引用约束，引用的记录不存在
+	-6626: gorm.ErrForeignKeyViolated,      // 违反引用约束，存在引用该记录的子记录
+}
+
+// Translate 在 gorm.Config.TranslateError 开启时将DM错误转换为gorm错误，
+// 返回的错误同时包装了原始的DmError，可以通过errors.As取得错误码和错误信息
+func (d Dialector) Translate(err error) error {
+	var dmErr *DmError
+	if errors.As(err, &dmErr) {
+		if translated, ok := errCodes[dmErr.ErrCode]; ok {
+			return fmt.Errorf("%w: %w", translated, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package dm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Explain 将参数替换为DM的常量写法，生成的SQL可以直接在DM工具中执行
+func (d Dialector) Explain(sql string, vars ...any) string {
+	var (
+		builder strings.Builder
+		idx     int
+		quote   byte
+	)
+	builder.Grow(len(sql))
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			// 引号中的?不是参数，''和""在关闭后立即重新打开，不影响判断
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?' && idx < len(vars):
+			builder.WriteString(explainValue(vars[idx]))
+			idx++
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String()
+}
+
+// explainValue 返回参数对应的DM常量
+func explainValue(v any) string {
+	// 先取出指针指向的值，只有指针实现了Valuer时才保留指针
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "NULL"
+		}
+		elem := rv.Elem().Interface()
+		_, ptrValuer := v.(driver.Valuer)
+		_, elemValuer := elem.(driver.Valuer)
+		if !ptrValuer || elemValuer {
+			return explainValue(elem)
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quoteString(v)
+	case json.RawMessage:
+		return quoteString(string(v))
+	case []byte:
+		return explainBytes(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return "TIMESTAMP '" + v.Format("2006-01-02 15:04:05.999999999 -07:00") + "'"
+	case DmDecimal:
+		if !v.Valid {
+			return "NULL"
+		}
+		return v.String()
+	case DmTimestamp:
+		return explainTimestamp(&v)
+	case DmIntervalDT:
+		if !v.Valid {
+			return "NULL"
+		}
+		return v.String()
+	case DmIntervalYM:
+		if !v.Valid {
+			return "NULL"
+		}
+		return v.String()
+	case DmBlob:
+		// 未从服务器读取完的大字段无法还原内容
+		if !v.Valid {
+			return "NULL"
+		} else if int64(len(v.data)) != v.length {
+			return "EMPTY_BLOB()"
+		}
+		return explainBytes(v.data)
+	case DmClob:
+		if !v.Valid {
+			return "NULL"
+		} else if int64(len(v.data)) != v.length {
+			return "EMPTY_CLOB()"
+		}
+		return quoteString(string(v.data))
+	case sql.Out:
+		// 输出参数只能绑定，保留占位符
+		return "?"
+	case sql.NamedArg:
+		return explainValue(v.Value)
+	case [][]any:
+		// 数组绑定的批量参数
+		return "?"
+	case driver.Valuer:
+		value, err := v.Value()
+		if err != nil {
+			return quoteString(fmt.Sprintf("%v", v))
+		}
+		return explainValue(value)
+	}
+
+	// 基础类型的自定义类型按数值输出，即使实现了String方法
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return explainValue(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits())
+	case reflect.String:
+		return quoteString(rv.String())
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return explainBytes(rv.Bytes())
+		}
+	}
+	if stringer, ok := v.(fmt.Stringer); ok {
+		return quoteString(stringer.String())
+	}
+	return quoteString(fmt.Sprintf("%v", v))
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// explainBytes 二进制数据使用十六进制常量
+func explainBytes(b []byte) string {
+	if len(b) == 0 {
+		return "''"
+	}
+	return "0x" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+func explainTimestamp(ts *DmTimestamp) string {
+	if !ts.Valid {
+		return "NULL"
+	}
+	value := dtToString(ts.dt, ts.dtype, ts.scale)
+	switch ts.dtype {
+	case DATE:
+		return "DATE '" + value + "'"
+	case TIME, TIME_TZ:
+		return "TIME '" + value + "'"
+	default:
+		return "TIMESTAMP '" + value + "'"
+	}
+}
@@ -0,0 +1,60 @@
+package dm
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// ContainsExpression 全文检索条件，列上需要有全文索引 `gorm:"index:,class:CONTEXT"`
+//
+//	db.Where(dm.Contains("content", "达梦", "数据库"))
+//	db.Where(dm.ContainsAny("content", "DM8", "DM7").Not("Oracle"))
+type ContainsExpression struct {
+	column string
+	terms  []string
+	op     string
+	not    []string
+}
+
+// Contains 同时包含所有检索词
+func Contains(column string, terms ...string) *ContainsExpression {
+	return &ContainsExpression{column: column, terms: terms, op: " AND "}
+}
+
+// ContainsAny 包含任意一个检索词
+func ContainsAny(column string, terms ...string) *ContainsExpression {
+	return &ContainsExpression{column: column, terms: terms, op: " OR "}
+}
+
+// Not 排除包含这些检索词的记录
+func (e *ContainsExpression) Not(terms ...string) *ContainsExpression {
+	e.not = append(e.not, terms...)
+	return e
+}
+
+func (e *ContainsExpression) Build(builder clause.Builder) {
+	// 只有排除的检索词或没有检索词时无法构造检索条件
+	if len(e.terms) == 0 {
+		builder.AddError(fmt.Errorf("CONTAINS on column %s requires at least one search term", e.column))
+		return
+	}
+	builder.WriteString("CONTAINS(")
+	builder.WriteQuoted(clause.Column{Name: e.column})
+	builder.WriteString(", ")
+	// 检索条件只能是字符串常量组成的表达式，不能绑定参数
+	quoted := make([]string, len(e.terms))
+	for i, term := range e.terms {
+		quoted[i] = quoteString(term)
+	}
+	if len(quoted) > 1 && len(e.not) > 0 {
+		builder.WriteString("(" + strings.Join(quoted, e.op) + ")")
+	} else {
+		builder.WriteString(strings.Join(quoted, e.op))
+	}
+	for _, term := range e.not {
+		builder.WriteString(" AND NOT " + quoteString(term))
+	}
+	builder.WriteByte(')')
+}
@@ -0,0 +1,103 @@
+package dm
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OptimizerHints 允许使用的优化器提示名称，需要其他提示（如可以作为提示使用的INI参数）时可以追加
+var OptimizerHints = map[string]struct{}{
+	"INDEX": {}, "NO_INDEX": {}, "FULL": {}, "USE_NL": {}, "NO_USE_NL": {}, "USE_HASH": {}, "NO_USE_HASH": {},
+	"USE_MERGE": {}, "NO_USE_MERGE": {}, "USE_NL_WITH_INDEX": {}, "USE_CVT_VAR": {}, "NO_USE_CVT_VAR": {},
+	"ORDERED": {}, "LEADING": {}, "PARALLEL": {}, "NO_PARALLEL": {}, "STAT": {}, "NO_EXPAND": {},
+	"ENABLE_HASH_JOIN": {}, "ENABLE_MERGE_JOIN": {}, "ENABLE_INDEX_JOIN": {}, "ENABLE_INDEX_FILTER": {},
+	"MAX_OPT_N_TABLES": {}, "OPTIMIZER_MODE": {}, "OPTIMIZER_DYNAMIC_SAMPLING": {}, "ADAPTIVE_NPLN_FLAG": {},
+	"OUTER_JOIN_FLATTEN_FLAG": {}, "VIEW_PULLUP_FLAG": {}, "GROUP_OPT_FLAG": {}, "REFED_EXISTS_OPT_FLAG": {},
+	"HAGR_HASH_SIZE": {}, "JOIN_HASH_SIZE": {}, "ENABLE_IN_VALUE_LIST_OPT": {}, "FIRST_ROWS": {},
+}
+
+// hintClauses 提示放在这些子句的关键字之后，MERGE 由MergeCreate生成
+var hintClauses = []string{"SELECT", "UPDATE", "DELETE", "MERGE"}
+
+// Hints 优化器提示，放在 SELECT/UPDATE/DELETE/MERGE 关键字之后
+//
+//	db.Clauses(dm.Hint("INDEX(users idx_users_name)", "PARALLEL(4)")).Find(&users)
+type Hints struct {
+	Content []string
+}
+
+func Hint(content ...string) Hints {
+	return Hints{Content: content}
+}
+
+func (hints Hints) ModifyStatement(stmt *gorm.Statement) {
+	for _, content := range hints.Content {
+		if err := validateHints(content); err != nil {
+			_ = stmt.AddError(err)
+			return
+		}
+	}
+
+	for _, name := range hintClauses {
+		c := stmt.Clauses[name]
+		if name == "DELETE" {
+			// clause.Delete 自己输出 DELETE 关键字，提示需要放在表达式之后
+			c.AfterExpression = hints
+		} else {
+			c.AfterNameExpression = hints
+		}
+		stmt.Clauses[name] = c
+	}
+}
+
+func (hints Hints) Build(builder clause.Builder) {
+	builder.WriteString("/*+ " + strings.Join(hints.Content, " ") + " */")
+}
+
+// validateHints 按括号外的空白拆分提示，检查提示名称
+func validateHints(content string) error {
+	if strings.Contains(content, "*/") {
+		return fmt.Errorf("invalid optimizer hint %q", content)
+	}
+
+	var (
+		depth int
+		start = -1
+	)
+	check := func(hint string) error {
+		name := hint
+		if idx := strings.IndexByte(hint, '('); idx >= 0 {
+			name = hint[:idx]
+		}
+		if _, ok := OptimizerHints[strings.ToUpper(strings.TrimSpace(name))]; !ok {
+			return fmt.Errorf("unknown optimizer hint %q", hint)
+		}
+		return nil
+	}
+	for i, c := range content + " " {
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (c == ' ' || c == '\t' || c == '\n' || c == ','):
+			if start >= 0 {
+				if err := check(content[start:i]); err != nil {
+					return err
+				}
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses in optimizer hint %q", content)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package dm
+
+import (
+	"strings"
+
+	"gorm.io/gorm/schema"
+)
+
+// upperCaseNamer 大写标识符模式下的命名策略，表名、列名、索引名等与不加引号创建的对象一致
+type upperCaseNamer struct {
+	schema.Namer
+}
+
+func (n upperCaseNamer) TableName(table string) string {
+	return strings.ToUpper(n.Namer.TableName(table))
+}
+
+func (n upperCaseNamer) SchemaName(table string) string {
+	return n.Namer.SchemaName(strings.ToLower(table))
+}
+
+func (n upperCaseNamer) ColumnName(table, column string) string {
+	return strings.ToUpper(n.Namer.ColumnName(table, column))
+}
+
+func (n upperCaseNamer) JoinTableName(joinTable string) string {
+	return strings.ToUpper(n.Namer.JoinTableName(joinTable))
+}
+
+func (n upperCaseNamer) RelationshipFKName(rel schema.Relationship) string {
+	return strings.ToUpper(n.Namer.RelationshipFKName(rel))
+}
+
+func (n upperCaseNamer) CheckerName(table, column string) string {
+	return strings.ToUpper(n.Namer.CheckerName(table, column))
+}
+
+func (n upperCaseNamer) IndexName(table, column string) string {
+	return strings.ToUpper(n.Namer.IndexName(table, column))
+}
+
+func (n upperCaseNamer) UniqueName(table, column string) string {
+	return strings.ToUpper(n.Namer.UniqueName(table, column))
+}
+
+// upperIdentifier 将标识符中的小写字母转为大写，与DM对不加引号的标识符的处理一致
+func upperIdentifier(str string) string {
+	return strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}, str)
+}
+
+// identifier 查询系统表时使用的对象名，大写标识符模式下转为大写
+func (m Migrator) identifier(name string) string {
+	if m.Dialector.Config != nil && m.UpperCaseIdentifiers {
+		return upperIdentifier(name)
+	}
+	return name
+}
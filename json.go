@@ -0,0 +1,116 @@
+package dm
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var jsonRawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// isJSON 字段是否为JSON：json.RawMessage、GormDataType()返回json或`gorm:"type:json"`
+func isJSON(field *schema.Field) bool {
+	if strings.EqualFold(string(field.DataType), "json") {
+		return true
+	}
+	fieldType := field.FieldType
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType == jsonRawMessageType
+}
+
+// JSONPathExpression JSON_VALUE、JSON_QUERY、JSON_EXISTS 表达式，可用于Where、Select和Order
+//
+//	db.Where(dm.JSONValue("attrs", "$.color").Eq("red"))
+//	db.Where(dm.JSONExists("attrs", "$.tags"))
+type JSONPathExpression struct {
+	function string
+	column   string
+	path     string
+	options  string
+	operator string
+	value    any
+}
+
+// JSONValue 取出标量值，可以通过Returning指定返回类型
+func JSONValue(column, path string) *JSONPathExpression {
+	return &JSONPathExpression{function: "JSON_VALUE", column: column, path: path}
+}
+
+// JSONQuery 取出对象或数组
+func JSONQuery(column, path string) *JSONPathExpression {
+	return &JSONPathExpression{function: "JSON_QUERY", column: column, path: path}
+}
+
+// JSONExists 路径在JSON中存在时为真
+func JSONExists(column, path string) *JSONPathExpression {
+	return &JSONPathExpression{function: "JSON_EXISTS", column: column, path: path}
+}
+
+// Returning 指定 JSON_VALUE 的返回类型，如 NUMBER、VARCHAR(100)
+func (e *JSONPathExpression) Returning(sqlType string) *JSONPathExpression {
+	e.options = " RETURNING " + sqlType
+	return e
+}
+
+// WithWrapper JSON_QUERY 的结果用数组包装，用于取出多个值
+func (e *JSONPathExpression) WithWrapper() *JSONPathExpression {
+	e.options = " WITH WRAPPER"
+	return e
+}
+
+// Eq 等于value，value为nil时为 IS NULL
+func (e *JSONPathExpression) Eq(value any) *JSONPathExpression {
+	return e.compare("=", value)
+}
+
+func (e *JSONPathExpression) Neq(value any) *JSONPathExpression {
+	return e.compare("<>", value)
+}
+
+func (e *JSONPathExpression) Gt(value any) *JSONPathExpression {
+	return e.compare(">", value)
+}
+
+func (e *JSONPathExpression) Gte(value any) *JSONPathExpression {
+	return e.compare(">=", value)
+}
+
+func (e *JSONPathExpression) Lt(value any) *JSONPathExpression {
+	return e.compare("<", value)
+}
+
+func (e *JSONPathExpression) Lte(value any) *JSONPathExpression {
+	return e.compare("<=", value)
+}
+
+func (e *JSONPathExpression) compare(operator string, value any) *JSONPathExpression {
+	e.operator = operator
+	e.value = value
+	return e
+}
+
+func (e *JSONPathExpression) Build(builder clause.Builder) {
+	builder.WriteString(e.function)
+	builder.WriteByte('(')
+	builder.WriteQuoted(clause.Column{Name: e.column})
+	// 路径只能是字符串常量，不能绑定参数
+	builder.WriteString(", '" + strings.ReplaceAll(e.path, "'", "''") + "'")
+	builder.WriteString(e.options)
+	builder.WriteByte(')')
+
+	switch {
+	case e.operator == "":
+	case e.value == nil && e.operator == "=":
+		builder.WriteString(" IS NULL")
+	case e.value == nil && e.operator == "<>":
+		builder.WriteString(" IS NOT NULL")
+	default:
+		builder.WriteString(" " + e.operator + " ")
+		builder.AddVar(builder, e.value)
+	}
+}
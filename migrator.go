@@ -1,9 +1,15 @@
 package dm
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"gorm.io/gorm"
@@ -14,6 +20,8 @@ import (
 
 var (
 	regFullDataType = regexp.MustCompile(`\D*(\d+)\D?`)
+	// 时间类型的精度写在类型名和时区之间，如 TIMESTAMP(3) WITH TIME ZONE
+	regTimePrecision = regexp.MustCompile(`\(\d+\)`)
 )
 
 type Migrator struct {
@@ -22,10 +30,126 @@ type Migrator struct {
 }
 
 func (m Migrator) AutoMigrate(dst ...any) error {
-	return m.Migrator.AutoMigrate(dst...)
+	// 已存在的表是否为全局临时表需要与模型一致
+	for _, value := range dst {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			if m.HasTable(value) {
+				return m.checkTemporaryTable(stmt)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := m.Migrator.AutoMigrate(dst...); err != nil {
+		return err
+	}
+
+	// 定义改变的外键和CHECK约束在父类添加新列之后删除并按模型重新创建，约束可能引用新添加的列
+	for _, value := range dst {
+		if err := m.recreateDriftedConstraints(value); err != nil {
+			return err
+		}
+	}
+
+	// 同步表注释
+	for _, value := range dst {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			comment, ok := m.tableComment(stmt)
+			if !ok {
+				return nil
+			}
+			tableType, err := m.TableType(value)
+			if errors.Is(err, sql.ErrNoRows) {
+				// 生成迁移计划时表还未创建，建表时已经包含了注释
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if current, _ := tableType.Comment(); current != comment {
+				m := m.because("table %s comment changed", stmt.Table)
+				return m.DB.Exec("COMMENT ON TABLE ? IS "+m.Explain("?", comment), m.CurrentTable(stmt)).Error
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrationStep 迁移计划中的一条DDL及其原因
+type MigrationStep struct {
+	SQL    string
+	Vars   []any
+	Reason string
+}
+
+// MigrationPlan 按执行顺序排列的迁移步骤
+type MigrationPlan []MigrationStep
+
+func (plan MigrationPlan) String() string {
+	var builder strings.Builder
+	for _, step := range plan {
+		if step.Reason != "" {
+			builder.WriteString("-- " + step.Reason + "\n")
+		}
+		builder.WriteString(step.SQL + ";\n")
+	}
+	return builder.String()
+}
+
+// PlanMigrate 使用与AutoMigrate相同的比较逻辑，返回需要执行的DDL而不执行；查询系统表的语句照常执行
+func (m Migrator) PlanMigrate(dst ...any) (MigrationPlan, error) {
+	var plan MigrationPlan
+	tx := m.DB.WithContext(m.DB.Statement.Context)
+	tx.Statement.ConnPool = &planConnPool{ConnPool: m.DB.Statement.ConnPool, plan: &plan}
+	if err := tx.Migrator().AutoMigrate(dst...); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ApplyMigrationPlan 按顺序执行审核过的迁移计划
+func (m Migrator) ApplyMigrationPlan(plan MigrationPlan) error {
+	for _, step := range plan {
+		if err := m.DB.Exec(step.SQL, step.Vars...).Error; err != nil {
+			return fmt.Errorf("failed to apply migration step %q: %w", step.SQL, err)
+		}
+	}
+	return nil
+}
+
+type migrationReasonKey struct{}
+
+// planConnPool 生成迁移计划时使用，查询交给原连接执行，DDL只记录到计划中
+type planConnPool struct {
+	gorm.ConnPool
+	plan *MigrationPlan
+}
+
+func (p *planConnPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	reason, _ := ctx.Value(migrationReasonKey{}).(string)
+	*p.plan = append(*p.plan, MigrationStep{SQL: query, Vars: args, Reason: reason})
+	return driver.RowsAffected(0), nil
+}
+
+// because 生成迁移计划时，为接下来执行的DDL记录原因
+func (m Migrator) because(format string, args ...any) Migrator {
+	if _, ok := m.DB.Statement.ConnPool.(*planConnPool); ok {
+		m.DB = m.DB.WithContext(context.WithValue(m.DB.Statement.Context, migrationReasonKey{}, fmt.Sprintf(format, args...)))
+	}
+	return m
 }
 
+// CurrentDatabase 返回当前模式。通过DSN打开连接时Config.Schema就是会话的模式，直接使用；
+// 使用Config.Conn时Config.Schema不会应用到连接上，查询会话实际的模式
 func (m Migrator) CurrentDatabase() (name string) {
+	if m.Dialector.Config != nil && m.Schema != "" && m.Conn == nil {
+		return m.Schema
+	}
+
 	if err := m.DB.Raw("SELECT SYS_CONTEXT('USERENV', 'CURRENT_SCHEMA');").Row().Scan(&name); err != nil {
 		return ""
 	}
@@ -33,26 +157,31 @@ func (m Migrator) CurrentDatabase() (name string) {
 	return
 }
 
+// FullDataTypeOf 列注释不能写在列定义中，由 COMMENT ON COLUMN 单独设置
 func (m Migrator) FullDataTypeOf(field *schema.Field) clause.Expr {
-	expr := m.Migrator.FullDataTypeOf(field)
-
-	if value, ok := field.TagSettings["COMMENT"]; ok {
-		expr.SQL += " COMMENT " + m.Explain("?", value)
-	}
-
-	return expr
+	return m.Migrator.FullDataTypeOf(field)
 }
 
 func (m Migrator) GetTypeAliases(databaseTypeName string) []string {
 	// super
-	return m.Migrator.GetTypeAliases(databaseTypeName)
+	aliases := m.Migrator.GetTypeAliases(databaseTypeName)
+	// 只在Oracle兼容模式下把Oracle写法视为相同类型，避免改变DM原有的类型比较
+	if m.CompatibleOracle {
+		aliases = append(aliases, oracleTypeAliases[databaseTypeName]...)
+	}
+	return aliases
 }
 
 func (m Migrator) CreateTable(values ...any) error {
-	// 将`gorm:"default:true"`转为`gorm:"default:1"`
+	// 创建字段使用的序列；将`gorm:"default:true"`转为`gorm:"default:1"`
 	for _, value := range values {
 		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
 			for _, v := range stmt.Schema.Fields {
+				if seq := sequenceOf(v); seq != "" && !m.HasSequence(seq) {
+					if err := m.because("sequence %s does not exist", seq).CreateSequence(seq); err != nil {
+						return err
+					}
+				}
 				if v.HasDefaultValue {
 					if vv, ok := v.DefaultValueInterface.(bool); ok {
 						if vv {
@@ -68,8 +197,170 @@ func (m Migrator) CreateTable(values ...any) error {
 			return err
 		}
 	}
-	// super
-	return m.Migrator.CreateTable(values...)
+
+	// 表选项中的 COMMENT '...' 不是DM的建表语法，改为建表后 COMMENT ON TABLE；分区子句追加到表选项后，
+	// 全局临时表的 ON COMMIT 子句放在表选项前
+	var options string
+	if tableOption, ok := m.DB.Get("gorm:table_options"); ok {
+		options, _, _ = splitTableComment(fmt.Sprint(tableOption))
+	}
+	for _, value := range values {
+		creator := m
+		opts := options
+		temporary := false
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			creator = m.because("table %s does not exist", stmt.Table)
+			if onCommit, ok := temporaryTable(stmt); ok {
+				opts = " ON COMMIT " + string(onCommit) + opts
+				temporary = true
+			}
+			if partition, ok := tablePartition(stmt); ok {
+				opts += " " + partition.build(stmt)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		// 始终覆盖原来的表选项，其中的COMMENT已经拆分出去，即使剩下的选项为空
+		creator.DB = creator.DB.Set("gorm:table_options", opts)
+		if temporary {
+			creator.DB = creator.DB.Session(&gorm.Session{})
+			creator.DB.Statement.ConnPool = temporaryConnPool{creator.DB.Statement.ConnPool}
+		}
+
+		// super
+		if err := creator.Migrator.CreateTable(value); err != nil {
+			return err
+		}
+	}
+
+	for _, value := range values {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			m := m.because("comments of new table %s", stmt.Table)
+			if comment, ok := m.tableComment(stmt); ok {
+				if err := m.DB.Exec("COMMENT ON TABLE ? IS "+m.Explain("?", comment), m.CurrentTable(stmt)).Error; err != nil {
+					return err
+				}
+			}
+			for _, dbName := range stmt.Schema.DBNames {
+				field := stmt.Schema.FieldsByDBName[dbName]
+				if field.Comment != "" && !field.IgnoreMigration {
+					if err := m.commentColumn(stmt, field); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitTableName 拆分 schema.table 形式的表名，未指定模式时使用当前模式
+func (m Migrator) splitTableName(table string) (schemaName, tableName string) {
+	table = m.identifier(strings.ReplaceAll(table, `"`, ""))
+	if idx := strings.LastIndexByte(table, '.'); idx >= 0 {
+		return table[:idx], table[idx+1:]
+	}
+	return m.identifier(m.CurrentDatabase()), table
+}
+
+// tableSchema 返回语句对应表的模式名和表名，支持 TableName() 返回的 schema.table 和 db.Table("schema.table")
+func (m Migrator) tableSchema(stmt *gorm.Statement) (schemaName, tableName string) {
+	if schemaName = explicitSchema(stmt); schemaName != "" {
+		return m.identifier(schemaName), m.identifier(stmt.Table[strings.LastIndexByte(stmt.Table, '.')+1:])
+	}
+	return m.splitTableName(stmt.Table)
+}
+
+// explicitSchema 返回表名中显式指定的模式，未指定时返回空串
+func explicitSchema(stmt *gorm.Statement) string {
+	table := stmt.Table
+	if stmt.TableExpr != nil && len(stmt.TableExpr.Vars) == 0 && !strings.Contains(table, ".") {
+		// gorm 解析 schema.table 时会把表名拆到 TableExpr 中
+		if expr := strings.ReplaceAll(stmt.TableExpr.SQL, `"`, ""); strings.Count(expr, ".") == 1 && strings.HasSuffix(expr, "."+table) {
+			table = expr
+		}
+	}
+	if idx := strings.LastIndexByte(table, '.'); idx >= 0 {
+		return strings.ReplaceAll(table[:idx], `"`, "")
+	}
+	return ""
+}
+
+// qualifiedName 表名带有模式时，索引等对象名也需要加上同样的模式
+func (m Migrator) qualifiedName(stmt *gorm.Statement, name string) any {
+	if schemaName := explicitSchema(stmt); schemaName != "" {
+		return clause.Table{Name: schemaName + "." + name}
+	}
+	return clause.Column{Name: name}
+}
+
+// TableCommenter 模型实现该接口时，建表和AutoMigrate会通过 COMMENT ON TABLE 设置表注释
+type TableCommenter interface {
+	TableComment() string
+}
+
+var regTableComment = regexp.MustCompile(`(?i)\bCOMMENT\s*=?\s*'((?:[^']|'')*)'`)
+
+// splitTableComment 从表选项中分离出 COMMENT '...'，返回去掉注释后的表选项和注释内容
+func splitTableComment(options string) (string, string, bool) {
+	matches := regTableComment.FindStringSubmatchIndex(options)
+	if matches == nil {
+		return options, "", false
+	}
+	comment := strings.ReplaceAll(options[matches[2]:matches[3]], "''", "'")
+	return strings.TrimRight(options[:matches[0]]+options[matches[1]:], " "), comment, true
+}
+
+// tableComment 返回模型指定的表注释，来自TableCommenter接口或表选项中的 COMMENT '...'
+func (m Migrator) tableComment(stmt *gorm.Statement) (string, bool) {
+	if stmt.Schema != nil {
+		if commenter, ok := reflect.New(stmt.Schema.ModelType).Interface().(TableCommenter); ok {
+			return commenter.TableComment(), true
+		}
+	}
+	if tableOption, ok := m.DB.Get("gorm:table_options"); ok {
+		if _, comment, ok := splitTableComment(fmt.Sprint(tableOption)); ok {
+			return comment, true
+		}
+	}
+	return "", false
+}
+
+func (m Migrator) commentColumn(stmt *gorm.Statement, field *schema.Field) error {
+	return m.DB.Exec(
+		"COMMENT ON COLUMN ?.? IS "+m.Explain("?", field.Comment),
+		m.CurrentTable(stmt), clause.Column{Name: field.DBName},
+	).Error
+}
+
+// TableType 返回表的类型（BASE TABLE或VIEW）和表注释
+func (m Migrator) TableType(value any) (gorm.TableType, error) {
+	tableSql := `SELECT /*+ MAX_OPT_N_TABLES(5) */ TABS.SUBTYPE$,
+(SELECT COMMENT$ FROM SYS.SYSTABLECOMMENTS WHERE SCHNAME = ? AND TVNAME = ?) FROM
+(SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) SCHS,
+(SELECT SCHID, SUBTYPE$ FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ IN ('UTAB', 'STAB', 'VIEW') AND NAME = ?) TABS
+WHERE TABS.SCHID = SCHS.ID;`
+
+	var tableType migrator.TableType
+	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		schemaName, tableName := m.tableSchema(stmt)
+		tableType.SchemaValue = schemaName
+		tableType.NameValue = tableName
+		if err := m.DB.Raw(tableSql, schemaName, tableName, schemaName, tableName).Row().Scan(&tableType.TypeValue, &tableType.CommentValue); err != nil {
+			return err
+		}
+		if m.hasMaterializedView(schemaName, tableName) {
+			tableType.TypeValue = "MATERIALIZED VIEW"
+		} else if tableType.TypeValue != "VIEW" {
+			tableType.TypeValue = "BASE TABLE"
+		}
+		return nil
+	})
+	return tableType, err
 }
 
 func (m Migrator) DropTable(values ...any) error {
@@ -77,7 +368,20 @@ func (m Migrator) DropTable(values ...any) error {
 	for i := len(values) - 1; i >= 0; i-- {
 		tx := m.DB.Session(&gorm.Session{})
 		if err := m.RunWithValue(values[i], func(stmt *gorm.Statement) error {
-			return tx.Exec("DROP TABLE IF EXISTS ? CASCADE", m.CurrentTable(stmt)).Error
+			if err := tx.Exec("DROP TABLE IF EXISTS ? CASCADE", m.CurrentTable(stmt)).Error; err != nil {
+				return err
+			}
+			// 删除随表创建的序列
+			if stmt.Schema != nil {
+				for _, field := range stmt.Schema.Fields {
+					if seq := sequenceOf(field); seq != "" && m.HasSequence(seq) {
+						if err := m.DropSequence(seq); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			return nil
 		}); err != nil {
 			return err
 		}
@@ -85,6 +389,209 @@ func (m Migrator) DropTable(values ...any) error {
 	return nil
 }
 
+// CreateSequence 创建序列，用于`gorm:"sequence:SEQ_NAME"`字段
+func (m Migrator) CreateSequence(name string) error {
+	return m.DB.Exec("CREATE SEQUENCE ? START WITH 1 INCREMENT BY 1", clause.Table{Name: name}).Error
+}
+
+func (m Migrator) DropSequence(name string) error {
+	return m.DB.Exec("DROP SEQUENCE ?", clause.Table{Name: name}).Error
+}
+
+// HasSequence 序列是否存在，name可以带模式名，如 SCH.SEQ_NAME
+func (m Migrator) HasSequence(name string) bool {
+	seqSql := `SELECT /*+ MAX_OPT_N_TABLES(5) */ COUNT(SEQS.NAME) FROM
+(SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) SCHS,
+(SELECT SCHID, NAME FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ = 'SEQ' AND NAME = ?) SEQS
+WHERE SEQS.SCHID = SCHS.ID;`
+
+	schemaName, name := m.splitTableName(name)
+
+	var count int64
+	if err := m.DB.Raw(seqSql, schemaName, name).Row().Scan(&count); err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// PartitionType 分区方式
+type PartitionType string
+
+const (
+	PartitionRange PartitionType = "RANGE"
+	PartitionList  PartitionType = "LIST"
+	PartitionHash  PartitionType = "HASH"
+)
+
+// Partition 单个分区，LessThan用于范围分区，Values用于列表分区，哈希分区只需要Name
+type Partition struct {
+	Name     string
+	LessThan string // 如 '2024-01-01' 或 MAXVALUE
+	Values   string // 如 'BJ', 'TJ' 或 DEFAULT
+}
+
+func (p Partition) build(stmt *gorm.Statement) string {
+	sql := "PARTITION " + stmt.Quote(clause.Column{Name: p.Name})
+	if p.LessThan != "" {
+		sql += " VALUES LESS THAN (" + p.LessThan + ")"
+	} else if p.Values != "" {
+		sql += " VALUES (" + p.Values + ")"
+	}
+	return sql
+}
+
+// TablePartition 表的分区定义，哈希分区未指定Partitions时按Count生成分区
+type TablePartition struct {
+	Type       PartitionType
+	Columns    []string
+	Partitions []Partition
+	Count      int
+}
+
+func (p TablePartition) build(stmt *gorm.Statement) string {
+	columns := make([]string, len(p.Columns))
+	for i, column := range p.Columns {
+		if stmt.Schema != nil {
+			if field := stmt.Schema.LookUpField(column); field != nil {
+				column = field.DBName
+			}
+		}
+		columns[i] = stmt.Quote(clause.Column{Name: column})
+	}
+
+	sql := "PARTITION BY " + string(p.Type) + "(" + strings.Join(columns, ",") + ")"
+	if len(p.Partitions) == 0 {
+		if p.Count > 0 {
+			sql += " PARTITIONS " + strconv.Itoa(p.Count)
+		}
+		return sql
+	}
+
+	partitions := make([]string, len(p.Partitions))
+	for i, partition := range p.Partitions {
+		partitions[i] = partition.build(stmt)
+	}
+	return sql + " (" + strings.Join(partitions, ", ") + ")"
+}
+
+// TablePartitioner 模型实现该接口时，CreateTable按返回的定义创建分区表；
+// 也可以直接在 gorm:table_options 中写 PARTITION BY 子句
+type TablePartitioner interface {
+	TablePartition() TablePartition
+}
+
+func tablePartition(stmt *gorm.Statement) (TablePartition, bool) {
+	if stmt.Schema != nil {
+		if partitioner, ok := reflect.New(stmt.Schema.ModelType).Interface().(TablePartitioner); ok {
+			return partitioner.TablePartition(), true
+		}
+	}
+	return TablePartition{}, false
+}
+
+// AddPartition 为范围或列表分区表增加分区
+func (m Migrator) AddPartition(value any, partition Partition) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Exec("ALTER TABLE ? ADD "+partition.build(stmt), m.CurrentTable(stmt)).Error
+	})
+}
+
+func (m Migrator) DropPartition(value any, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Exec("ALTER TABLE ? DROP PARTITION ?", m.CurrentTable(stmt), clause.Column{Name: name}).Error
+	})
+}
+
+// SplitPartition 将分区拆分为两个分区，范围分区在bound处拆分，列表分区将bound中的值拆出到into[0]
+func (m Migrator) SplitPartition(value any, name string, bound string, into [2]string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		partitionType, err := m.partitionType(stmt)
+		if err != nil {
+			return err
+		}
+
+		var sql string
+		switch partitionType {
+		case PartitionRange:
+			sql = "ALTER TABLE ? SPLIT PARTITION ? AT (" + bound + ") INTO (PARTITION ?, PARTITION ?)"
+		case PartitionList:
+			sql = "ALTER TABLE ? SPLIT PARTITION ? VALUES (" + bound + ") INTO (PARTITION ?, PARTITION ?)"
+		default:
+			return fmt.Errorf("can not split %s partition %s", partitionType, name)
+		}
+		return m.DB.Exec(sql, m.CurrentTable(stmt), clause.Column{Name: name},
+			clause.Column{Name: into[0]}, clause.Column{Name: into[1]}).Error
+	})
+}
+
+// MergePartitions 将两个相邻分区合并为一个分区
+func (m Migrator) MergePartitions(value any, names [2]string, into string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Exec("ALTER TABLE ? MERGE PARTITIONS ?, ? INTO PARTITION ?", m.CurrentTable(stmt),
+			clause.Column{Name: names[0]}, clause.Column{Name: names[1]}, clause.Column{Name: into}).Error
+	})
+}
+
+// partitionType 优先使用模型的分区定义，否则从系统表中查询
+func (m Migrator) partitionType(stmt *gorm.Statement) (PartitionType, error) {
+	if partition, ok := tablePartition(stmt); ok {
+		return partition.Type, nil
+	}
+
+	partitions, partitionType, err := m.getPartitions(stmt)
+	if err == nil && len(partitions) == 0 {
+		err = fmt.Errorf("table %s is not partitioned", stmt.Table)
+	}
+	return partitionType, err
+}
+
+// GetPartitions 返回分区表的分区列表，按分区顺序排列
+func (m Migrator) GetPartitions(value any) (partitions []Partition, err error) {
+	err = m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		partitions, _, err = m.getPartitions(stmt)
+		return err
+	})
+	return
+}
+
+func (m Migrator) getPartitions(stmt *gorm.Statement) ([]Partition, PartitionType, error) {
+	partitionSql := `SELECT /*+ MAX_OPT_N_TABLES(5) */ PARTS.PARTITION_NAME, PARTS.PARTITION_TYPE, PARTS.HIGH_VALUE FROM
+(SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) SCHS,
+(SELECT ID, SCHID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ = 'UTAB' AND NAME = ?) TABS,
+SYS.SYSHPARTTABLEINFO PARTS
+WHERE TABS.SCHID = SCHS.ID AND PARTS.BASE_TABLE_ID = TABS.ID
+ORDER BY PARTS.PART_TABLE_ID;`
+
+	schemaName, tableName := m.tableSchema(stmt)
+	rows, err := m.DB.Raw(partitionSql, schemaName, tableName).Rows()
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var (
+		partitions    []Partition
+		partitionType PartitionType
+	)
+	for rows.Next() {
+		var (
+			partition Partition
+			highValue sql.NullString
+		)
+		if err := rows.Scan(&partition.Name, &partitionType, &highValue); err != nil {
+			return nil, "", err
+		}
+		switch partitionType {
+		case PartitionRange:
+			partition.LessThan = highValue.String
+		case PartitionList:
+			partition.Values = highValue.String
+		}
+		partitions = append(partitions, partition)
+	}
+	return partitions, partitionType, rows.Err()
+}
+
 func (m Migrator) HasTable(value any) bool {
 	tableSql := `SELECT /*+ MAX_OPT_N_TABLES(5) */ COUNT(TABS.NAME) FROM
 (SELECT ID, PID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) SCHEMAS,
@@ -96,7 +603,17 @@ WHERE TABS.SCHID = SCHEMAS.ID AND SF_CHECK_PRIV_OPT(UID(), CURRENT_USERTYPE(), T
 
 	var count int64
 	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		return m.DB.Raw(tableSql, m.CurrentDatabase(), stmt.Table).Row().Scan(&count)
+		schemaName, tableName := m.tableSchema(stmt)
+		if err := m.DB.Raw(tableSql, schemaName, tableName).Row().Scan(&count); err != nil {
+			return err
+		}
+		// 全局临时表和物化视图可能被INFO3的条件排除
+		if count == 0 {
+			if _, ok := m.temporaryTable(stmt); ok || m.hasMaterializedView(schemaName, tableName) {
+				count = 1
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return false
@@ -105,6 +622,19 @@ WHERE TABS.SCHID = SCHEMAS.ID AND SF_CHECK_PRIV_OPT(UID(), CURRENT_USERTYPE(), T
 }
 
 func (m Migrator) RenameTable(oldName, newName any) error {
+	// RENAME TO 的新表名不能带模式名，表仍在原模式下
+	if v, ok := newName.(string); ok {
+		if idx := strings.LastIndexByte(v, '.'); idx >= 0 {
+			newName = v[idx+1:]
+		}
+	} else {
+		stmt := &gorm.Statement{DB: m.DB}
+		if err := stmt.Parse(newName); err != nil {
+			return err
+		}
+		_, newName = m.tableSchema(stmt)
+	}
+
 	// super
 	return m.Migrator.RenameTable(oldName, newName)
 }
@@ -115,15 +645,27 @@ func (m Migrator) GetTables() (tableList []string, err error) {
 (SELECT ID, SCHID, NAME FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ IN ('UTAB', 'STAB', 'VIEW', 'SYNOM')
 AND ((SUBTYPE$ ='UTAB' AND CAST((INFO3 & 0x00FF & 0x003F) AS INT) not in (9, 27, 29, 25, 12, 7, 21, 23, 18, 5))
 OR SUBTYPE$ in ('STAB', 'VIEW', 'SYNOM'))) TABS
-WHERE TABS.SCHID = SCHEMAS.ID AND SF_CHECK_PRIV_OPT(UID(), CURRENT_USERTYPE(), TABS.ID, SCHEMAS.PID, -1, TABS.ID) = 1;`
+WHERE TABS.SCHID = SCHEMAS.ID AND SF_CHECK_PRIV_OPT(UID(), CURRENT_USERTYPE(), TABS.ID, SCHEMAS.PID, -1, TABS.ID) = 1
+UNION SELECT TABLE_NAME FROM ALL_TABLES WHERE OWNER = ? AND TEMPORARY = 'Y'
+UNION SELECT MVIEW_NAME FROM ALL_MVIEWS WHERE OWNER = ?;`
 
-	err = m.DB.Raw(tableSql, m.CurrentDatabase()).Scan(&tableList).Error
+	currentDatabase := m.identifier(m.CurrentDatabase())
+	err = m.DB.Raw(tableSql, currentDatabase, currentDatabase, currentDatabase).Scan(&tableList).Error
 	return
 }
 
 func (m Migrator) AddColumn(dst any, field string) error {
 	// super
-	return m.Migrator.AddColumn(dst, field)
+	if err := m.because("column %s does not exist", field).Migrator.AddColumn(dst, field); err != nil {
+		return err
+	}
+	// 新增列的注释
+	return m.RunWithValue(dst, func(stmt *gorm.Statement) error {
+		if f := stmt.Schema.LookUpField(field); f != nil && f.Comment != "" {
+			return m.commentColumn(stmt, f)
+		}
+		return nil
+	})
 }
 
 func (m Migrator) DropColumn(dst any, field string) error {
@@ -132,32 +674,50 @@ func (m Migrator) DropColumn(dst any, field string) error {
 }
 
 func (m Migrator) AlterColumn(value any, field string) error {
-	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		if field := stmt.Schema.LookUpField(field); field != nil {
-			return m.DB.Exec(
-				"ALTER TABLE ? MODIFY ? ?",
-				clause.Table{Name: stmt.Table},
-				clause.Column{Name: field.DBName},
-				m.FullDataTypeOf(field),
-			).Error
+	// 查询不到列信息时直接MODIFY
+	var current gorm.ColumnType
+	if columnTypes, err := m.DB.Migrator().ColumnTypes(value); err == nil {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			if f := stmt.Schema.LookUpField(field); f != nil {
+				for _, columnType := range columnTypes {
+					if columnType.Name() == f.DBName {
+						current = columnType
+					}
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to look up field with name: %s", field)
-	})
+	}
+	return m.alterColumn(value, field, current)
 }
 
-// containsUnique: 原本是否就有UNIQUE字段
-func (m Migrator) alterColumn(value any, field string, containsUnique bool) error {
+// columnType: 数据库中列的当前信息，不能直接MODIFY的类型修改通过临时列转换，为nil时直接MODIFY
+func (m Migrator) alterColumn(value any, field string, columnType gorm.ColumnType) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		if field := stmt.Schema.LookUpField(field); field != nil {
+			containsUnique := false
+			if columnType != nil {
+				if unique, ok := columnType.Unique(); ok {
+					containsUnique = unique
+				}
+				if m.needsConversion(field, columnType) {
+					return m.convertColumn(stmt, field)
+				}
+			}
+
 			typeof := m.FullDataTypeOf(field)
 			// 如果列原本就有UNIQUE，且修改后仍有UNIQUE，则在MODIFY COLUMN时不再添加UNIQUE字段
 			// 这样也不会影响 有UNIQUE -> 无UNIQUE、无UNIQUE -> 有UNIQUE、无UNIQUE -> 无UNIQUE的情况
 			if containsUnique && field.Unique {
 				typeof.SQL = strings.Replace(typeof.SQL, " UNIQUE", "", 1)
 			}
+			// JSON列的CHECK约束建表或添加列时已创建
+			typeof.SQL, _ = splitCheck(typeof.SQL)
 			return m.DB.Exec(
 				"ALTER TABLE ? MODIFY ? ?",
-				clause.Table{Name: stmt.Table},
+				m.CurrentTable(stmt),
 				clause.Column{Name: field.DBName},
 				typeof,
 			).Error
@@ -170,28 +730,30 @@ func (m Migrator) MigrateColumn(dst any, field *schema.Field, columnType gorm.Co
 	// super
 	// return m.Migrator.MigrateColumn(dst, field, columnType)
 	// bug629968 不再使用父类默认的MigrateColumn函数，主要修改：
-	// 添加了containsUnique参数和最后的调用从AlterColumn改为alterColumn
+	// 添加了columnType参数和最后的调用从AlterColumn改为alterColumn
 
 	// found, smart migrate
 	fullDataType := strings.TrimSpace(strings.ToLower(m.DB.Migrator().FullDataTypeOf(field).SQL))
 	realDataType := strings.ToLower(columnType.DatabaseTypeName())
-	containsUnique := false
-	if unique, ok := columnType.Unique(); ok {
-		containsUnique = unique
-	}
-
 	var (
 		alterColumn bool
 		isSameType  = fullDataType == realDataType
+		reasons     []string
 	)
 
+	// 系统表中的时间类型不含精度，去掉精度后再比较类型，精度由下面的precision检查比较
+	compareDataType := fullDataType
+	if field.DataType == schema.Time {
+		compareDataType = regTimePrecision.ReplaceAllString(fullDataType, "")
+	}
+
 	if !field.PrimaryKey {
 		// check type
-		if !strings.HasPrefix(fullDataType, realDataType) {
+		if !strings.HasPrefix(compareDataType, realDataType) {
 			// check type aliases
 			aliases := m.DB.Migrator().GetTypeAliases(realDataType)
 			for _, alias := range aliases {
-				if strings.HasPrefix(fullDataType, alias) {
+				if strings.HasPrefix(compareDataType, alias) {
 					isSameType = true
 					break
 				}
@@ -199,6 +761,7 @@ func (m Migrator) MigrateColumn(dst any, field *schema.Field, columnType gorm.Co
 
 			if !isSameType {
 				alterColumn = true
+				reasons = append(reasons, fmt.Sprintf("type %s -> %s", realDataType, fullDataType))
 			}
 		}
 	}
@@ -208,6 +771,7 @@ func (m Migrator) MigrateColumn(dst any, field *schema.Field, columnType gorm.Co
 		if length, ok := columnType.Length(); length != int64(field.Size) {
 			if length > 0 && field.Size > 0 {
 				alterColumn = true
+				reasons = append(reasons, fmt.Sprintf("size %d -> %d", length, field.Size))
 			} else {
 				// has size in data type and not equal
 				// Since the following code is frequently called in the for loop, reg optimization is needed here
@@ -215,6 +779,7 @@ func (m Migrator) MigrateColumn(dst any, field *schema.Field, columnType gorm.Co
 				if !field.PrimaryKey &&
 					(len(matches2) == 1 && matches2[0][1] != fmt.Sprint(length) && ok) {
 					alterColumn = true
+					reasons = append(reasons, fmt.Sprintf("size %d -> %s", length, matches2[0][1]))
 				}
 			}
 		}
@@ -223,15 +788,25 @@ func (m Migrator) MigrateColumn(dst any, field *schema.Field, columnType gorm.Co
 		if precision, _, ok := columnType.DecimalSize(); ok && int64(field.Precision) != precision {
 			if regexp.MustCompile(fmt.Sprintf("[^0-9]%d[^0-9]", field.Precision)).MatchString(m.Migrator.DataTypeOf(field)) {
 				alterColumn = true
+				reasons = append(reasons, fmt.Sprintf("precision %d -> %d", precision, field.Precision))
 			}
 		}
 	}
 
+	// check time zone，TIMESTAMP 是 TIMESTAMP WITH TIME ZONE 的前缀，需要单独比较
+	if field.DataType == schema.Time && !field.PrimaryKey && !alterColumn {
+		if from, to := timeZoneOf(realDataType), timeZoneOf(fullDataType); from != to {
+			alterColumn = true
+			reasons = append(reasons, fmt.Sprintf("time zone %q -> %q", from, to))
+		}
+	}
+
 	// check nullable
 	if nullable, ok := columnType.Nullable(); ok && nullable == field.NotNull {
 		// not primary key & database is nullable
 		if !field.PrimaryKey && nullable {
 			alterColumn = true
+			reasons = append(reasons, "nullable -> not null")
 		}
 	}
 
@@ -240,44 +815,90 @@ func (m Migrator) MigrateColumn(dst any, field *schema.Field, columnType gorm.Co
 		// not primary key
 		if !field.PrimaryKey {
 			alterColumn = true
+			reasons = append(reasons, fmt.Sprintf("unique %t -> %t", unique, field.Unique))
 		}
 	}
 
 	// check default value
 	if !field.PrimaryKey {
 		currentDefaultNotNull := field.HasDefaultValue && (field.DefaultValueInterface != nil || !strings.EqualFold(field.DefaultValue, "NULL"))
+		if m.CompatibleOracle && field.DefaultValue == "''" {
+			// Oracle兼容模式下空字符串默认值就是NULL
+			currentDefaultNotNull = false
+		}
 		dv, dvNotNull := columnType.DefaultValue()
 		if dvNotNull && !currentDefaultNotNull {
 			// defalut value -> null
 			alterColumn = true
+			reasons = append(reasons, fmt.Sprintf("default %s -> null", dv))
 		} else if !dvNotNull && currentDefaultNotNull {
 			// null -> default value
 			alterColumn = true
+			reasons = append(reasons, fmt.Sprintf("default null -> %s", field.DefaultValue))
 		} else if (field.GORMDataType != schema.Time && dv != field.DefaultValue) ||
 			(field.GORMDataType == schema.Time && !strings.EqualFold(strings.TrimSuffix(dv, "()"), strings.TrimSuffix(field.DefaultValue, "()"))) {
 			// default value not equal
 			// not both null
 			if currentDefaultNotNull || dvNotNull {
 				alterColumn = true
+				reasons = append(reasons, fmt.Sprintf("default %s -> %s", dv, field.DefaultValue))
 			}
 		}
 	}
 
-	// check comment
-	if comment, ok := columnType.Comment(); ok && comment != field.Comment {
-		// not primary key
-		if !field.PrimaryKey {
-			alterColumn = true
+	if alterColumn && !field.IgnoreMigration {
+		if err := m.DB.Migrator().(Migrator).because("column %s changed: %s", field.DBName, strings.Join(reasons, ", ")).alterColumn(dst, field.DBName, columnType); err != nil {
+			return err
 		}
 	}
 
-	if alterColumn && !field.IgnoreMigration {
-		return m.DB.Migrator().(Migrator).alterColumn(dst, field.DBName, containsUnique)
+	// check comment，注释通过 COMMENT ON COLUMN 修改，不需要MODIFY列
+	if comment, ok := columnType.Comment(); ok && comment != field.Comment && !field.IgnoreMigration {
+		return m.RunWithValue(dst, func(stmt *gorm.Statement) error {
+			return m.because("column %s comment changed", field.DBName).commentColumn(stmt, field)
+		})
 	}
 
 	return nil
 }
 
+// localTimeZoneScaleMask SYSCOLUMNS.SCALE中 WITH LOCAL TIME ZONE 的标志位
+const localTimeZoneScaleMask = 0x1000
+
+// normalizeTimeType 将系统表中的时间类型统一为建表时使用的类型名
+func normalizeTimeType(dataType string, scale int64) (string, bool) {
+	dataType = strings.ToUpper(dataType)
+	switch {
+	case dataType == "DATE":
+		return "DATE", true
+	case strings.HasPrefix(dataType, "TIME "), dataType == "TIME":
+		if strings.Contains(dataType, "TIME ZONE") {
+			return "TIME WITH TIME ZONE", true
+		}
+		return "TIME", true
+	case strings.HasPrefix(dataType, "TIMESTAMP"), strings.HasPrefix(dataType, "DATETIME"):
+		if strings.Contains(dataType, "TIME ZONE") {
+			return "TIMESTAMP WITH TIME ZONE", true
+		} else if scale&localTimeZoneScaleMask != 0 {
+			return "TIMESTAMP WITH LOCAL TIME ZONE", true
+		}
+		return "TIMESTAMP", true
+	}
+	return "", false
+}
+
+// timeZoneOf 返回时间类型的时区部分
+func timeZoneOf(dataType string) string {
+	dataType = strings.ToLower(dataType)
+	// 完整的列定义中时区后面还可能有 NOT NULL、DEFAULT 等
+	for _, zone := range []string{" with local time zone", " with time zone"} {
+		if strings.Contains(dataType, zone) {
+			return zone
+		}
+	}
+	return ""
+}
+
 func (m Migrator) HasColumn(value any, field string) bool {
 	columnSql := `SELECT /*+ MAX_OPT_N_TABLES(5) */ COUNT(DISTINCT COLS.NAME) FROM
 (SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) SCHS,
@@ -287,7 +908,13 @@ WHERE TABS.ID = COLS.ID AND SCHS.ID = TABS.SCHID;`
 
 	var count int64
 	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		return m.DB.Raw(columnSql, m.CurrentDatabase(), stmt.Table, field).Row().Scan(&count)
+		if stmt.Schema != nil {
+			if f := stmt.Schema.LookUpField(field); f != nil {
+				field = f.DBName
+			}
+		}
+		schemaName, tableName := m.tableSchema(stmt)
+		return m.DB.Raw(columnSql, schemaName, tableName, m.identifier(field)).Row().Scan(&count)
 	})
 	if err != nil {
 		return false
@@ -304,114 +931,211 @@ func (m Migrator) ColumnTypes(dst any) ([]gorm.ColumnType, error) {
 	columnTypes := make([]gorm.ColumnType, 0)
 	execErr := m.RunWithValue(dst, func(stmt *gorm.Statement) error {
 		var (
-			currentDatabase = m.CurrentDatabase()
-			table           = stmt.Table
-			columnTypeSQL   = `SELECT /*+ MAX_OPT_N_TABLES(5) */ COLS.NAME, COLS.DEFVAL FROM
+			currentDatabase, table = m.tableSchema(stmt)
+			columnTypeSQL          = `SELECT /*+ MAX_OPT_N_TABLES(5) */ COLS.NAME, COLS.TYPE$, COLS.LENGTH$, COLS.SCALE, COLS.NULLABLE$,
+COLS.DEFVAL, BITAND(COLS.INFO2, 1), (SELECT COMMENT$ FROM SYS.SYSCOLUMNCOMMENTS WHERE SCHNAME = ? AND TVNAME = ? AND COLNAME = COLS.NAME) FROM
 (SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) SCHS,
 (SELECT ID, SCHID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ IN ('UTAB', 'STAB', 'VIEW') AND NAME = ?) TABS,
 SYS.SYSCOLUMNS COLS
-WHERE TABS.ID=COLS.ID AND SCHS.ID = TABS.SCHID`
-			columnConsSQL = `SELECT /*+ MAX_OPT_N_TABLES(5) */ COLS.NAME, LNNVL(CONS.TYPE$!='P'), LNNVL(CONS.TYPE$!='U') FROM
+WHERE TABS.ID=COLS.ID AND SCHS.ID = TABS.SCHID ORDER BY COLS.COLID`
+			// 主键列，以及只有一列的唯一索引（含唯一约束）中的列
+			columnIndexSQL = `SELECT /*+ MAX_OPT_N_TABLES(5) */ COLS.NAME, LNNVL(CONS.TYPE$!='P'), LNNVL(INDS.ISUNIQUE!='Y' OR INDS.KEYNUM!=1) FROM
 (SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) SCHS,
 (SELECT ID, SCHID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ IN ('UTAB', 'STAB', 'VIEW') AND NAME = ?) TABS,
-SYS.SYSCOLUMNS COLS,
-SYS.SYSCONS CONS,
-SYS.SYSINDEXES INDS
-WHERE SCHS.ID=TABS.SCHID AND TABS.ID=COLS.ID AND COLS.ID=CONS.TABLEID and CONS.INDEXID=INDS.ID and SF_COL_IS_IDX_KEY(INDS.KEYNUM, INDS.KEYINFO, COLS.COLID)=1`
-			consMap   = make(map[string][][]bool)
-			rows, err = m.DB.Session(&gorm.Session{}).Table(stmt.Table).Limit(1).Rows()
+SYS.SYSCOLUMNS COLS, SYS.SYSINDEXES INDS,
+(SELECT ID, PID FROM SYS.SYSOBJECTS WHERE SUBTYPE$ = 'INDEX') OBJ_INDS LEFT JOIN SYS.SYSCONS CONS ON CONS.INDEXID = OBJ_INDS.ID
+WHERE SCHS.ID=TABS.SCHID AND TABS.ID=COLS.ID AND TABS.ID=OBJ_INDS.PID AND INDS.ID=OBJ_INDS.ID
+AND SF_COL_IS_IDX_KEY(INDS.KEYNUM, INDS.KEYINFO, COLS.COLID)=1`
+			primaryKeys = make(map[string]bool)
+			uniques     = make(map[string]bool)
 		)
 
+		// 索引
+		indexes, err := m.DB.Raw(columnIndexSQL, currentDatabase, table).Rows()
 		if err != nil {
 			return err
 		}
+		defer indexes.Close()
 
-		rawColumnTypes, err := rows.ColumnTypes()
-		if err != nil {
-			return err
-		}
-
-		if err = rows.Close(); err != nil {
-			return err
-		}
-
-		// 约束
-		cons, consErr := m.DB.Table(table).Raw(columnConsSQL, currentDatabase, table).Rows()
-		if consErr != nil {
-			return consErr
-		}
-		defer cons.Close()
-
-		for cons.Next() {
+		for indexes.Next() {
 			var (
-				colName string
-				values  = make([]bool, 2)
+				colName           string
+				primary, isUnique bool
 			)
-			if scanErr := cons.Scan(&colName, &values[0], &values[1]); scanErr != nil {
+			if scanErr := indexes.Scan(&colName, &primary, &isUnique); scanErr != nil {
 				return scanErr
 			}
-
-			if consMap[colName] == nil {
-				consMap[colName] = *new([][]bool)
-			}
-			consMap[colName] = append(consMap[colName], values)
+			primaryKeys[colName] = primaryKeys[colName] || primary
+			uniques[colName] = uniques[colName] || isUnique
 		}
 
-		// 列信息
-		columns, rowErr := m.DB.Table(table).Raw(columnTypeSQL, currentDatabase, table).Rows()
-		if rowErr != nil {
-			return rowErr
+		// 列信息，只查询系统表，不读取表中的数据，空表和视图也可以取得完整的信息
+		columns, err := m.DB.Raw(columnTypeSQL, currentDatabase, table, currentDatabase, table).Rows()
+		if err != nil {
+			return err
 		}
 		defer columns.Close()
 
 		for columns.Next() {
 			var (
-				column migrator.ColumnType
-				values = []any{
-					&column.NameValue, &column.DefaultValueValue,
-				}
+				column   ColumnType
+				dataType string
+				length   int64
+				scale    int64
+				nullable string
 			)
-
-			if scanErr := columns.Scan(values...); scanErr != nil {
+			c := &column.column
+			if scanErr := columns.Scan(&c.NameValue, &dataType, &length, &scale, &nullable,
+				&c.DefaultValueValue, &c.AutoIncrementValue.Bool, &c.CommentValue); scanErr != nil {
 				return scanErr
 			}
 
-			column.DefaultValueValue.String = strings.Trim(column.DefaultValueValue.String, "'")
-
-			// 设置列的主键和值唯一信息
-			for key, value := range consMap {
-				if key == column.NameValue.String {
-					for _, con := range value {
-						if con[0] {
-							column.PrimaryKeyValue.Bool = true
-							column.PrimaryKeyValue.Valid = true
-						}
-						if con[1] {
-							column.UniqueValue.Bool = true
-							column.UniqueValue.Valid = true
-						}
-					}
-					break
-				}
-			}
-
-			// 设置默认的列信息（来自go驱动标准类型sql.ColumnType）
-			for _, c := range rawColumnTypes {
-				if c.Name() == column.NameValue.String {
-					column.SQLColumnType = c
-					break
-				}
-			}
+			name := c.NameValue.String
+			column.fill(dataType, length, scale)
+			c.NullableValue = sql.NullBool{Bool: nullable == "Y", Valid: true}
+			c.ScanTypeValue = scanTypeOf(c.DataTypeValue.String, c.NullableValue.Bool)
+			c.DefaultValueValue.String = strings.Trim(c.DefaultValueValue.String, "'")
+			// INFO2的最低位表示自增列
+			c.AutoIncrementValue.Valid = true
+			// 没有注释时视为空注释，使AutoMigrate能够清除已删除的注释
+			c.CommentValue.Valid = true
+			c.PrimaryKeyValue = sql.NullBool{Bool: primaryKeys[name], Valid: true}
+			c.UniqueValue = sql.NullBool{Bool: uniques[name], Valid: true}
 
 			columnTypes = append(columnTypes, column)
 		}
 
-		return nil
+		return columns.Err()
 	})
 
 	return columnTypes, execErr
 }
 
+var (
+	lengthTypes  = []string{"CHAR", "CHARACTER", "VARCHAR", "VARCHAR2", "NVARCHAR2", "BINARY", "VARBINARY", "RAW"}
+	decimalTypes = []string{"DECIMAL", "DEC", "NUMERIC", "NUMBER"}
+)
+
+// ColumnType ColumnTypes返回的列信息，全部来自系统表：
+// 只有字符串、二进制和大字段类型有长度，只有DECIMAL等数值类型和时间类型有精度
+type ColumnType struct {
+	column migrator.ColumnType
+}
+
+// fill 根据系统表中的类型、长度和小数位数设置类型信息
+func (ct *ColumnType) fill(dataType string, length, scale int64) {
+	dataType = strings.ToUpper(dataType)
+	columnType := dataType
+	switch {
+	case slices.Contains(lengthTypes, dataType):
+		ct.column.LengthValue = sql.NullInt64{Int64: length, Valid: true}
+		columnType = fmt.Sprintf("%s(%d)", dataType, length)
+	case slices.Contains(lobTypes, dataType):
+		ct.column.LengthValue = sql.NullInt64{Int64: length, Valid: true}
+	case slices.Contains(decimalTypes, dataType):
+		if length == 0 {
+			length = 38
+		}
+		ct.column.DecimalSizeValue = sql.NullInt64{Int64: length, Valid: true}
+		ct.column.ScaleValue = sql.NullInt64{Int64: scale, Valid: true}
+		columnType = fmt.Sprintf("%s(%d,%d)", dataType, length, scale)
+	default:
+		// 时间类型的小数秒精度和时区保存在SCALE中
+		if timeType, ok := normalizeTimeType(dataType, scale); ok {
+			precision := scale &^ localTimeZoneScaleMask
+			dataType, columnType = timeType, timeType
+			ct.column.DecimalSizeValue = sql.NullInt64{Int64: precision, Valid: true}
+			if name, _, _ := strings.Cut(timeType, " "); name != "DATE" {
+				columnType = fmt.Sprintf("%s(%d)", name, precision) + strings.TrimPrefix(timeType, name)
+			}
+		}
+	}
+	ct.column.DataTypeValue = sql.NullString{String: dataType, Valid: true}
+	ct.column.ColumnTypeValue = sql.NullString{String: columnType, Valid: true}
+}
+
+func (ct ColumnType) Name() string {
+	return ct.column.NameValue.String
+}
+
+func (ct ColumnType) DatabaseTypeName() string {
+	return ct.column.DataTypeValue.String
+}
+
+func (ct ColumnType) ColumnType() (columnType string, ok bool) {
+	return ct.column.ColumnType()
+}
+
+func (ct ColumnType) PrimaryKey() (isPrimaryKey bool, ok bool) {
+	return ct.column.PrimaryKey()
+}
+
+func (ct ColumnType) AutoIncrement() (isAutoIncrement bool, ok bool) {
+	return ct.column.AutoIncrement()
+}
+
+func (ct ColumnType) Length() (length int64, ok bool) {
+	return ct.column.LengthValue.Int64, ct.column.LengthValue.Valid
+}
+
+func (ct ColumnType) DecimalSize() (precision int64, scale int64, ok bool) {
+	return ct.column.DecimalSizeValue.Int64, ct.column.ScaleValue.Int64, ct.column.DecimalSizeValue.Valid
+}
+
+func (ct ColumnType) Nullable() (nullable bool, ok bool) {
+	return ct.column.NullableValue.Bool, ct.column.NullableValue.Valid
+}
+
+func (ct ColumnType) Unique() (unique bool, ok bool) {
+	return ct.column.Unique()
+}
+
+func (ct ColumnType) ScanType() reflect.Type {
+	return ct.column.ScanTypeValue
+}
+
+func (ct ColumnType) Comment() (value string, ok bool) {
+	return ct.column.Comment()
+}
+
+func (ct ColumnType) DefaultValue() (value string, ok bool) {
+	return ct.column.DefaultValue()
+}
+
+// scanTypeOf 与驱动返回的ScanType一致
+func scanTypeOf(dataType string, nullable bool) reflect.Type {
+	choose := func(notNull, null reflect.Type) reflect.Type {
+		if nullable {
+			return null
+		}
+		return notNull
+	}
+	switch dataType {
+	case "BOOLEAN":
+		return choose(scanTypeBool, scanTypeNullBool)
+	case "BIT", "TINYINT", "BYTE":
+		return choose(scanTypeInt8, scanTypeNullInt)
+	case "SMALLINT":
+		return choose(scanTypeInt16, scanTypeNullInt)
+	case "INT", "INTEGER", "PLS_INTEGER":
+		return choose(scanTypeInt32, scanTypeNullInt)
+	case "BIGINT":
+		return choose(scanTypeInt64, scanTypeNullInt)
+	case "REAL", "FLOAT":
+		return choose(scanTypeFloat32, scanTypeNullFloat)
+	case "DOUBLE", "DOUBLE PRECISION", "BINARY_DOUBLE":
+		return choose(scanTypeFloat64, scanTypeNullFloat)
+	case "CHAR", "CHARACTER", "VARCHAR", "VARCHAR2", "NVARCHAR2", "CLOB", "TEXT", "LONGVARCHAR":
+		return choose(scanTypeString, scanTypeNullString)
+	case "DECIMAL", "DEC", "NUMERIC", "NUMBER", "BINARY", "VARBINARY", "RAW", "BLOB", "IMAGE", "LONGVARBINARY":
+		return scanTypeRawBytes
+	}
+	if _, ok := normalizeTimeType(dataType, 0); ok {
+		return choose(scanTypeTime, scanTypeNullTime)
+	}
+	return scanTypeUnknown
+}
+
 func (m Migrator) CreateView(name string, option gorm.ViewOption) error {
 	// super, not support
 	return m.Migrator.CreateView(name, option)
@@ -424,7 +1148,7 @@ func (m Migrator) DropView(name string) error {
 
 func (m Migrator) CreateConstraint(dst any, name string) error {
 	// super
-	return m.Migrator.CreateConstraint(dst, name)
+	return m.because("constraint %s does not exist", name).Migrator.CreateConstraint(dst, name)
 }
 
 func (m Migrator) DropConstraint(value any, name string) error {
@@ -434,7 +1158,7 @@ func (m Migrator) DropConstraint(value any, name string) error {
 
 func (m Migrator) HasConstraint(value any, name string) bool {
 	conSql := `select count(CON_OBJ.NAME) from
-(select ID from SYSOBJECTS where TYPE$='SCH' and NAME = ?) SCH_OBJ, 
+(select ID from SYSOBJECTS where TYPE$='SCH' and NAME = ?) SCH_OBJ,
 (select ID, SCHID from SYSOBJECTS where TYPE$='SCHOBJ' and SUBTYPE$ like '_TAB') TAB_OBJ, 
 (select ID, NAME from SYSOBJECTS where SUBTYPE$ = 'CONS' and NAME=?) CON_OBJ,
 SYSCONS CONS
@@ -442,29 +1166,64 @@ where CON_OBJ.ID=CONS.ID and TAB_OBJ.ID=CONS.TABLEID and TAB_OBJ.SCHID=SCH_OBJ.I
 
 	var count int64
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		constraint, chk := m.GuessConstraintInterfaceAndTable(stmt, name)
+		constraint, table := m.GuessConstraintInterfaceAndTable(stmt, name)
 		if constraint != nil {
 			name = constraint.GetName()
-		} else if chk != "" {
-			name = chk
 		}
-		return m.DB.Raw(conSql, m.CurrentDatabase(), name).Row().Scan(&count)
+		schemaName, _ := m.tableSchema(stmt)
+		if table != stmt.Table {
+			schemaName, _ = m.splitTableName(table)
+		}
+		return m.DB.Raw(conSql, schemaName, m.identifier(name)).Row().Scan(&count)
 	})
 	return count > 0
 }
 
-func (m Migrator) CreateIndex(dst any, name string) error {
-	// super
-	return m.Migrator.CreateIndex(dst, name)
+func (m Migrator) CreateIndex(value any, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if stmt.Schema == nil {
+			return errors.New("failed to get schema")
+		}
+		if idx := stmt.Schema.LookIndex(name); idx != nil {
+			// 全文索引只能建在单个列上
+			if isContextClass(idx.Class) && len(idx.Fields) != 1 {
+				return fmt.Errorf("context index %s must have exactly one column", idx.Name)
+			}
+			opts := m.DB.Migrator().(migrator.BuildIndexOptionsInterface).BuildIndexOptions(idx.Fields, stmt)
+			values := []any{m.qualifiedName(stmt, idx.Name), m.CurrentTable(stmt), opts}
+
+			createIndexSQL := "CREATE "
+			if idx.Class != "" {
+				createIndexSQL += idx.Class + " "
+			}
+			createIndexSQL += "INDEX ? ON ??"
+
+			if idx.Option != "" {
+				createIndexSQL += " " + idx.Option
+			}
+
+			return m.because("index %s does not exist", idx.Name).DB.Exec(createIndexSQL, values...).Error
+		}
+
+		return fmt.Errorf("failed to create index with name %s", name)
+	})
 }
 
 func (m Migrator) DropIndex(value any, name string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		if idx := stmt.Schema.LookIndex(name); idx != nil {
-			name = idx.Name
+		if stmt.Schema != nil {
+			if idx := stmt.Schema.LookIndex(name); idx != nil {
+				name = idx.Name
+				if isContextClass(idx.Class) {
+					return m.DB.Exec("DROP CONTEXT INDEX ? ON ?", clause.Column{Name: name}, m.CurrentTable(stmt)).Error
+				}
+			}
 		}
 
-		return m.DB.Exec("DROP INDEX ?", clause.Column{Name: name}).Error
+		if m.hasContextIndex(stmt, name) {
+			return m.DB.Exec("DROP CONTEXT INDEX ? ON ?", clause.Column{Name: name}, m.CurrentTable(stmt)).Error
+		}
+		return m.DB.Exec("DROP INDEX ?", m.qualifiedName(stmt, name)).Error
 	})
 }
 
@@ -481,19 +1240,39 @@ WHERE TAB.ID = COLS.ID AND TAB.ID = OBJ_INDS.TABLEID AND COLS.COLID = OBJ_INDS.C
 
 	var count int64
 	m.RunWithValue(value, func(stmt *gorm.Statement) error {
-		if idx := stmt.Schema.LookIndex(name); idx != nil {
-			name = idx.Name
+		if stmt.Schema != nil {
+			if idx := stmt.Schema.LookIndex(name); idx != nil {
+				name = idx.Name
+			}
 		}
-		return m.DB.Raw(indexSql, m.CurrentDatabase(), stmt.Schema.Table, name, name).Row().Scan(&count)
+		schemaName, tableName := m.tableSchema(stmt)
+		name = m.identifier(name)
+		return m.DB.Raw(indexSql, schemaName, tableName, name, name).Row().Scan(&count)
 	})
 	return count > 0
 }
 
+// hasContextIndex 索引是否为全文索引，全文索引需要使用 DROP CONTEXT INDEX 删除
+func (m Migrator) hasContextIndex(stmt *gorm.Statement, name string) bool {
+	if m.DB.DryRun {
+		return false
+	}
+	contextSql := `SELECT /*+ MAX_OPT_N_TABLES(5) */ COUNT(*) FROM
+(SELECT ID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCH' AND NAME = ?) USERS,
+(SELECT ID, SCHID FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ = 'UTAB' AND NAME = ?) TAB,
+SYSCONTEXTINDEXES AS OBJ_INDS WHERE TAB.SCHID = USERS.ID AND TAB.ID = OBJ_INDS.TABLEID AND OBJ_INDS.NAME = ?`
+
+	var count int64
+	schemaName, tableName := m.tableSchema(stmt)
+	m.DB.Raw(contextSql, schemaName, tableName, m.identifier(name)).Row().Scan(&count)
+	return count > 0
+}
+
 func (m Migrator) RenameIndex(value any, oldName, newName string) error {
 	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		return m.DB.Exec(
 			"ALTER INDEX ? RENAME TO ?",
-			clause.Column{Name: oldName}, clause.Column{Name: newName},
+			m.qualifiedName(stmt, oldName), clause.Column{Name: newName},
 		).Error
 	})
 }
@@ -503,17 +1282,18 @@ func (m Migrator) GetIndexes(value any) ([]gorm.Index, error) {
 TAB(ID,SCHID,NAME) AS (SELECT ID, SCHID, NAME FROM SYS.SYSOBJECTS WHERE TYPE$ = 'SCHOBJ' AND SUBTYPE$ = 'UTAB' AND NAME = ?)
 SELECT /*+ MAX_OPT_N_TABLES(5) */ TAB.NAME AS TABLE_NAME, COLS.NAME AS COLUMN_NAME,
 OBJ_INDS.NAME AS INDEX_NAME, CASE INDS.ISUNIQUE WHEN 'Y' THEN 0 ELSE 1 END AS NON_UNIQUE,
-CASE OBJ_INDS.TYPE$ WHEN 'P' THEN 1 ELSE 0 END AS IS_PRIMARY FROM USERS, TAB, SYS.SYSINDEXES AS INDS, SYS.SYSCOLUMNS AS COLS,
+CASE OBJ_INDS.TYPE$ WHEN 'P' THEN 1 ELSE 0 END AS IS_PRIMARY, 0 AS IS_CONTEXT FROM USERS, TAB, SYS.SYSINDEXES AS INDS, SYS.SYSCOLUMNS AS COLS,
 (SELECT INDS.ID, INDS.PID, INDS.NAME, CONS.TYPE$ FROM SYS.SYSOBJECTS AS INDS LEFT JOIN SYS.SYSCONS AS CONS ON CONS.INDEXID=INDS.ID AND SUBTYPE$='INDEX') OBJ_INDS
 WHERE TAB.ID =COLS.ID AND TAB.ID =OBJ_INDS.PID AND INDS.ID=OBJ_INDS.ID AND TAB.SCHID=USERS.ID AND SF_COL_IS_IDX_KEY(INDS.KEYNUM, INDS.KEYINFO, COLS.COLID)=1
-UNION SELECT TAB.NAME AS TABLE_NAME, COLS.NAME AS COLUMN_NAME, OBJ_INDS.NAME AS INDEX_NAME, 1 AS NON_UNIQUE, 0 AS IS_PRIMARY FROM
+UNION SELECT TAB.NAME AS TABLE_NAME, COLS.NAME AS COLUMN_NAME, OBJ_INDS.NAME AS INDEX_NAME, 1 AS NON_UNIQUE, 0 AS IS_PRIMARY, 1 AS IS_CONTEXT FROM
 USERS, TAB, SYSCONTEXTINDEXES AS OBJ_INDS, SYS.SYSCOLUMNS AS COLS WHERE
 TAB.ID = COLS.ID AND TAB.ID = OBJ_INDS.TABLEID AND COLS.COLID = OBJ_INDS.COLID AND TAB.SCHID = USERS.ID;`
 
 	indexes := make([]gorm.Index, 0)
 	err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
 		result := make([]*Index, 0)
-		if scanErr := m.DB.Raw(indexSql, m.CurrentDatabase(), stmt.Table).Scan(&result).Error; scanErr != nil {
+		schemaName, tableName := m.tableSchema(stmt)
+		if scanErr := m.DB.Raw(indexSql, schemaName, tableName).Scan(&result).Error; scanErr != nil {
 			return scanErr
 		}
 		indexMap := groupByIndexName(result)
@@ -526,13 +1306,17 @@ TAB.ID = COLS.ID AND TAB.ID = OBJ_INDS.TABLEID AND COLS.COLID = OBJ_INDS.COLID A
 					Valid: true,
 				},
 				UniqueValue: sql.NullBool{
-					Bool:  idx[0].NonUnique,
+					Bool:  !idx[0].NonUnique,
 					Valid: true,
 				},
 			}
 			for _, x := range idx {
 				tempIdx.ColumnList = append(tempIdx.ColumnList, x.ColumnName)
 			}
+			if idx[0].Context {
+				indexes = append(indexes, ContextIndex{tempIdx})
+				continue
+			}
 			indexes = append(indexes, tempIdx)
 		}
 		return nil
@@ -546,6 +1330,20 @@ type Index struct {
 	IndexName  string `gorm:"column:INDEX_NAME"`
 	NonUnique  bool   `gorm:"column:NON_UNIQUE"`
 	Primary    bool   `gorm:"column:IS_PRIMARY"`
+	Context    bool   `gorm:"column:IS_CONTEXT"`
+}
+
+// ContextIndex GetIndexes返回的全文索引，对应 `gorm:"index:,class:CONTEXT"`
+type ContextIndex struct {
+	*migrator.Index
+}
+
+func (idx ContextIndex) Class() string {
+	return "CONTEXT"
+}
+
+func isContextClass(class string) bool {
+	return strings.EqualFold(class, "CONTEXT")
 }
 
 func groupByIndexName(indexList []*Index) map[string][]*Index {
@@ -0,0 +1,96 @@
+package dm
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefreshMethod 物化视图的刷新方法
+type RefreshMethod string
+
+const (
+	RefreshFast     RefreshMethod = "FAST"     // 增量刷新，需要在基表上建立物化视图日志
+	RefreshComplete RefreshMethod = "COMPLETE" // 重新执行查询
+	RefreshForce    RefreshMethod = "FORCE"    // 能增量刷新时增量刷新，否则完全刷新
+)
+
+// RefreshMode 物化视图的刷新时机
+type RefreshMode string
+
+const (
+	RefreshOnDemand RefreshMode = "ON DEMAND" // 调用 RefreshMaterializedView 时刷新
+	RefreshOnCommit RefreshMode = "ON COMMIT" // 基表的事务提交时刷新
+)
+
+// MaterializedViewOption 创建物化视图的选项，Method和Mode为空时使用数据库的默认值（FORCE ON DEMAND）
+type MaterializedViewOption struct {
+	Query *gorm.DB
+	// BuildDeferred 创建时不填充数据，第一次刷新时填充
+	BuildDeferred bool
+	Method        RefreshMethod
+	Mode          RefreshMode
+}
+
+// CreateMaterializedView 创建物化视图
+//
+//	m.CreateMaterializedView("order_totals", dm.MaterializedViewOption{
+//		Query:  db.Model(&Order{}).Select("user_id, SUM(amount) AS total").Group("user_id"),
+//		Method: dm.RefreshComplete,
+//		Mode:   dm.RefreshOnDemand,
+//	})
+func (m Migrator) CreateMaterializedView(name string, option MaterializedViewOption) error {
+	if option.Query == nil {
+		return gorm.ErrSubQueryRequired
+	}
+
+	sql := new(strings.Builder)
+	sql.WriteString("CREATE MATERIALIZED VIEW ")
+	m.QuoteTo(sql, name)
+	if option.BuildDeferred {
+		sql.WriteString(" BUILD DEFERRED")
+	}
+	if option.Method != "" || option.Mode != "" {
+		sql.WriteString(" REFRESH")
+		if option.Method != "" {
+			sql.WriteString(" " + string(option.Method))
+		}
+		if option.Mode != "" {
+			sql.WriteString(" " + string(option.Mode))
+		}
+	}
+	sql.WriteString(" AS ")
+
+	m.DB.Statement.AddVar(sql, option.Query)
+	return m.because("materialized view %s does not exist", name).DB.Exec(m.Explain(sql.String(), m.DB.Statement.Vars...)).Error
+}
+
+func (m Migrator) DropMaterializedView(name string) error {
+	if !m.HasMaterializedView(name) {
+		return nil
+	}
+	return m.DB.Exec("DROP MATERIALIZED VIEW ?", clause.Table{Name: name}).Error
+}
+
+// RefreshMaterializedView 刷新物化视图，method为空时使用创建时指定的刷新方法
+func (m Migrator) RefreshMaterializedView(name string, method RefreshMethod) error {
+	sql := "REFRESH MATERIALIZED VIEW ?"
+	if method != "" {
+		sql += " " + string(method)
+	}
+	return m.DB.Exec(sql, clause.Table{Name: name}).Error
+}
+
+func (m Migrator) HasMaterializedView(name string) bool {
+	schemaName, name := m.splitTableName(name)
+	return m.hasMaterializedView(schemaName, name)
+}
+
+func (m Migrator) hasMaterializedView(schemaName, name string) bool {
+	var count int64
+	if err := m.DB.Raw("SELECT COUNT(*) FROM ALL_MVIEWS WHERE OWNER = ? AND MVIEW_NAME = ?", schemaName, name).Row().Scan(&count); err != nil {
+		return false
+	}
+	return count > 0
+}
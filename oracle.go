@@ -0,0 +1,130 @@
+package dm
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// compatibleOracleDSN DSN中是否指定了 compatibleMode=oracle
+func compatibleOracleDSN(dsn string) bool {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return false
+	}
+	for name, values := range u.Query() {
+		if strings.EqualFold(name, CompatibleModeKey) && len(values) > 0 {
+			value := values[0]
+			return strings.EqualFold(value, "oracle") || value == strconv.Itoa(COMPATIBLE_MODE_ORACLE)
+		}
+	}
+	return false
+}
+
+// oracleDataTypeOf Oracle兼容模式下的列类型，时间、JSON和自定义类型与DM相同
+func (d Dialector) oracleDataTypeOf(field *schema.Field) (string, bool) {
+	switch field.DataType {
+	case schema.Bool:
+		return "NUMBER(1)", true
+	case schema.Int, schema.Uint:
+		var sqlType string
+		switch {
+		case field.Size <= 8:
+			sqlType = "NUMBER(3)"
+		case field.Size <= 16:
+			sqlType = "NUMBER(5)"
+		case field.Size <= 32:
+			sqlType = "NUMBER(10)"
+		default:
+			sqlType = "NUMBER(19)"
+		}
+		if isIdentity(field) {
+			sqlType += " IDENTITY(1,1)"
+		}
+		return sqlType, true
+	case schema.Float:
+		if field.Precision > 0 {
+			return fmt.Sprintf("NUMBER(%d, %d)", field.Precision, field.Scale), true
+		}
+		return "BINARY_DOUBLE", true
+	case schema.String:
+		return strings.Replace(d.getSchemaStringType(field), "VARCHAR", "VARCHAR2", 1), true
+	case schema.Bytes:
+		if field.Size > 0 && field.Size < 32768 {
+			return fmt.Sprintf("RAW(%d)", field.Size), true
+		}
+		return "BLOB", true
+	}
+	return "", false
+}
+
+// oracleTypeAliases DM与Oracle写法的对应关系，迁移Oracle工具建的表时不会因为类型名不同修改列
+var oracleTypeAliases = map[string][]string{
+	"varchar":       {"varchar2", "nvarchar2", "character varying"},
+	"varchar2":      {"varchar", "nvarchar2"},
+	"nvarchar2":     {"varchar", "varchar2"},
+	"char":          {"character", "nchar"},
+	"number":        {"decimal", "numeric", "dec", "bit", "tinyint", "smallint", "int", "integer", "bigint"},
+	"decimal":       {"number", "numeric", "dec"},
+	"numeric":       {"number", "decimal", "dec"},
+	"bit":           {"number(1)"},
+	"tinyint":       {"number(3)"},
+	"smallint":      {"number(5)"},
+	"int":           {"integer", "number(10)"},
+	"integer":       {"int", "number(10)"},
+	"bigint":        {"number(19)"},
+	"double":        {"binary_double", "float", "double precision"},
+	"float":         {"binary_double", "double", "double precision"},
+	"binary_double": {"double", "float", "double precision"},
+	"varbinary":     {"raw"},
+	"raw":           {"varbinary"},
+}
+
+// buildOracleWhere Oracle兼容模式下 ” 等同于NULL，= ” 改为 IS NULL，<> ” 改为 IS NOT NULL。
+// 只处理 clause.Eq、clause.Neq 及由它们组成的条件，如 Where(map[string]any{"name": ""})，SQL字符串中的条件不做修改
+func buildOracleWhere(c clause.Clause, builder clause.Builder) {
+	if where, ok := c.Expression.(clause.Where); ok {
+		c.Expression = clause.Where{Exprs: emptyStringAsNull(where.Exprs)}
+	}
+	c.Build(builder)
+}
+
+func emptyStringAsNull(exprs []clause.Expression) []clause.Expression {
+	converted := make([]clause.Expression, len(exprs))
+	for i, expr := range exprs {
+		switch e := expr.(type) {
+		case clause.Eq:
+			if isEmptyString(e.Value) {
+				e.Value = nil
+			}
+			expr = e
+		case clause.Neq:
+			if isEmptyString(e.Value) {
+				e.Value = nil
+			}
+			expr = e
+		case clause.AndConditions:
+			expr = clause.AndConditions{Exprs: emptyStringAsNull(e.Exprs)}
+		case clause.OrConditions:
+			expr = clause.OrConditions{Exprs: emptyStringAsNull(e.Exprs)}
+		case clause.NotConditions:
+			expr = clause.NotConditions{Exprs: emptyStringAsNull(e.Exprs)}
+		}
+		converted[i] = expr
+	}
+	return converted
+}
+
+func isEmptyString(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case *string:
+		return v != nil && *v == ""
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package dm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// OnCommit 全局临时表在事务提交时对数据的处理方式
+type OnCommit string
+
+const (
+	OnCommitDeleteRows   OnCommit = "DELETE ROWS"   // 事务级，提交后清空
+	OnCommitPreserveRows OnCommit = "PRESERVE ROWS" // 会话级，会话结束后清空
+)
+
+// TemporaryTabler 模型实现该接口时创建为全局临时表
+//
+//	func (Staging) TemporaryTable() dm.OnCommit { return dm.OnCommitPreserveRows }
+type TemporaryTabler interface {
+	TemporaryTable() OnCommit
+}
+
+func temporaryTable(stmt *gorm.Statement) (OnCommit, bool) {
+	if stmt.Schema != nil {
+		if tabler, ok := reflect.New(stmt.Schema.ModelType).Interface().(TemporaryTabler); ok {
+			return tabler.TemporaryTable(), true
+		}
+	}
+	return "", false
+}
+
+// temporaryConnPool 将父类CreateTable生成的 CREATE TABLE 改为 CREATE GLOBAL TEMPORARY TABLE
+type temporaryConnPool struct {
+	gorm.ConnPool
+}
+
+func (p temporaryConnPool) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if strings.HasPrefix(query, "CREATE TABLE ") {
+		query = "CREATE GLOBAL TEMPORARY TABLE " + strings.TrimPrefix(query, "CREATE TABLE ")
+	}
+	return p.ConnPool.ExecContext(ctx, query, args...)
+}
+
+// TemporaryTable 查询表是否为全局临时表及其 ON COMMIT 方式，表不存在或为普通表时ok为false
+func (m Migrator) TemporaryTable(value any) (onCommit OnCommit, ok bool) {
+	m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		onCommit, ok = m.temporaryTable(stmt)
+		return nil
+	})
+	return
+}
+
+func (m Migrator) temporaryTable(stmt *gorm.Statement) (OnCommit, bool) {
+	var duration string
+	schemaName, tableName := m.tableSchema(stmt)
+	if err := m.DB.Raw("SELECT DURATION FROM ALL_TABLES WHERE OWNER = ? AND TABLE_NAME = ? AND TEMPORARY = 'Y'",
+		schemaName, tableName).Row().Scan(&duration); err != nil {
+		return "", false
+	}
+	if duration == "SYS$SESSION" {
+		return OnCommitPreserveRows, true
+	}
+	return OnCommitDeleteRows, true
+}
+
+// checkTemporaryTable 临时表与普通表之间、ON COMMIT 方式之间不能通过ALTER TABLE转换，需要删除后重建
+func (m Migrator) checkTemporaryTable(stmt *gorm.Statement) error {
+	expected, temporary := temporaryTable(stmt)
+	current, isTemporary := m.temporaryTable(stmt)
+	switch {
+	case temporary && !isTemporary:
+		return fmt.Errorf("table %s is not a global temporary table, drop it to recreate", stmt.Table)
+	case !temporary && isTemporary:
+		return fmt.Errorf("table %s is a global temporary table, drop it to recreate as a regular table", stmt.Table)
+	case temporary && current != expected:
+		return fmt.Errorf("global temporary table %s is ON COMMIT %s, drop it to recreate with ON COMMIT %s", stmt.Table, current, expected)
+	}
+	return nil
+}
@@ -370,6 +370,12 @@ func (stmt *DmStatement) exec(args []driver.Value) (*DmResult, error) {
 			tmpArg = append(tmpArg, row)
 		}
 		err = stmt.executeBatch(tmpArg)
+		if dmErr, ok := err.(*DmError); ok && dmErr.ErrCode == EC_BP_WITH_ERROR.ErrCode && stmt.execInfo != nil {
+			// 复制一份错误带上每行的执行结果，不修改共用的错误变量
+			batchErr := *dmErr
+			batchErr.updateCounts = stmt.execInfo.updateCounts
+			err = &batchErr
+		}
 	} else {
 		err = stmt.executeInner(args, Dm_build_1059)
 	}
@@ -88,6 +88,8 @@ type DmError struct {
 	ErrText string
 	stack   []uintptr
 	detail  string
+	// updateCounts 数组绑定部分行失败时每行的执行结果，失败的行小于0
+	updateCounts []int64
 }
 
 func newDmError(errCode int32, errText string) *DmError {
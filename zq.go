@@ -713,7 +713,7 @@ func (dm_build_1145 *dm_build_1113) dm_build_1104() {
 func (dm_build_1147 *dm_build_1113) dm_build_1146() error {
 	dm_build_1147.dm_build_1116 = dm_build_1147.dm_build_1114.dm_build_700.Dm_build_634(Dm_build_1010)
 	if dm_build_1147.dm_build_1116 < 0 && dm_build_1147.dm_build_1116 != EC_RN_EXCEED_ROWSET_SIZE.ErrCode {
-		return (&DmError{dm_build_1147.dm_build_1116, dm_build_1147.dm_build_1148(), nil, ""}).throw()
+		return (&DmError{dm_build_1147.dm_build_1116, dm_build_1147.dm_build_1148(), nil, "", nil}).throw()
 	} else if dm_build_1147.dm_build_1116 > 0 {
 
 	} else if dm_build_1147.dm_build_1115 == Dm_build_1004 || dm_build_1147.dm_build_1115 == Dm_build_978 {